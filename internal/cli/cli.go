@@ -0,0 +1,107 @@
+// Package cli implements sshh's non-interactive subcommands.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"sshh/internal/config"
+)
+
+// Exit codes returned by subcommands.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
+
+// Command runs a subcommand with its arguments and returns the process exit code.
+type Command func(args []string) int
+
+var commands = map[string]Command{
+	"ls":      runList,
+	"show":    runShow,
+	"add":     runAdd,
+	"edit":    runEdit,
+	"rm":      runRemove,
+	"exec":    runExec,
+	"cp":      runCopy,
+	"sftp":    runSFTP,
+	"export":  runExport,
+	"history": runHistory,
+	"doctor":  runDoctor,
+	"daemon":  runDaemon,
+	"tunnel":  runTunnel,
+	"help":    runHelp,
+	"-h":      runHelp,
+	"--help":  runHelp,
+}
+
+const helpText = `Usage:
+  sshh                       launch the interactive TUI
+  sshh <name>                connect to a saved server
+  sshh <name> -- <command>   run a one-off command on a saved server
+  sshh connect <name> ...    the same, for a server named like a subcommand
+
+Servers:
+  sshh ls [--tag TAG] [--group GROUP] [--json]
+  sshh show <name> [--json]
+  sshh add --name NAME --host HOST [--user USER] [--port PORT] [--key KEY]
+           [--tag TAG]... [--group GROUP] [--jump HOST]... [--proxy-command CMD]
+           [--option K=V]... [--remote-command CMD] [--request-tty MODE]
+           [--pre-hook CMD] [--post-hook CMD] [--notes TEXT] [--json]
+  sshh edit <name> [--name NEW] [--host HOST] ... [--json]
+  sshh rm <name> [--force]
+  sshh exec [-c N] <selector> -- <command>
+      selector: comma-separated names, globs or tags (tag:NAME for tags only)
+  sshh cp <local> <name>:<path>      upload (recursive)
+  sshh cp <name>:<path> <local>      download (recursive)
+  sshh sftp <name>
+  sshh export ssh-config [-o FILE] [--sync FILE | --no-sync]
+  sshh history [name] [-n N] [--json]   recent sessions, newest first
+  sshh doctor                           check servers, tunnels and setup
+
+Tunnels:
+  sshh tunnel up|down|run <name>
+  sshh tunnel ls
+  sshh daemon
+
+Exit codes: 0 success, 1 error, 2 usage, 3 server not found.
+`
+
+// runHelp implements `sshh help`.
+func runHelp(args []string) int {
+	fmt.Print(helpText)
+	return exitOK
+}
+
+// Lookup returns the subcommand registered under name.
+func Lookup(name string) (Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// fail prints an error to stderr and returns exitError.
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitError
+}
+
+// saved turns a save conflict into a warning: the save went through, over
+// changes another sshh made to the same entries. Other errors are returned.
+func saved(err error) error {
+	var conflict *config.ConflictError
+	if errors.As(err, &conflict) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// usage prints a usage line to stderr and returns exitUsage.
+func usage(line string) int {
+	fmt.Fprintf(os.Stderr, "Usage: %s\n", line)
+	return exitUsage
+}
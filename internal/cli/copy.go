@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/model"
+	"sshh/internal/sshexec"
+)
+
+const copyUsage = "sshh cp <local> <server>:<path> | sshh cp <server>:<path> <local>"
+
+// runCopy implements `sshh cp`. Exactly one side must be <server>:<path>
+// naming a saved server.
+func runCopy(args []string) int {
+	if len(args) != 2 {
+		return usage(copyUsage)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+
+	srcServer, srcPath := splitRemote(cfg, args[0])
+	dstServer, dstPath := splitRemote(cfg, args[1])
+	var s *model.Server
+	var tr sshexec.Transfer
+	switch {
+	case srcServer != nil && dstServer == nil:
+		s = srcServer
+		tr = sshexec.Transfer{Remote: srcPath, Local: args[1]}
+	case srcServer == nil && dstServer != nil:
+		s = dstServer
+		tr = sshexec.Transfer{Local: args[0], Remote: dstPath, Upload: true}
+	case srcServer != nil:
+		return fail(errors.New("copying between two servers is not supported"))
+	default:
+		return fail(errors.New("neither side names a saved server (expected <server>:<path>)"))
+	}
+	return RunCopy(*s, cfg.Servers, tr)
+}
+
+// RunCopy performs a transfer in the foreground and returns the exit code.
+func RunCopy(s model.Server, servers []model.Server, tr sshexec.Transfer) int {
+	cmd, err := sshexec.CopyCommand(s, servers, tr)
+	if err != nil {
+		return fail(err)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	from, to := tr.Local, s.Name+":"+tr.Remote
+	if !tr.Upload {
+		from, to = to, from
+	}
+	fmt.Printf("Copying %s → %s\n", from, to)
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		return fail(err)
+	}
+	fmt.Printf("Done in %s\n", time.Since(start).Round(100*time.Millisecond))
+	return exitOK
+}
+
+// runSFTP implements `sshh sftp <name>`.
+func runSFTP(args []string) int {
+	if len(args) != 1 {
+		return usage("sshh sftp <name>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	_, s := cfg.FindByName(args[0])
+	if s == nil {
+		return notFound(args[0])
+	}
+
+	cmd, err := sshexec.SFTPCommand(*s, cfg.Servers)
+	if err != nil {
+		return fail(err)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		return fail(err)
+	}
+	return exitOK
+}
+
+// splitRemote parses <server>:<path> where server is a saved server name.
+// Returns nil if arg is a local path.
+func splitRemote(cfg *config.Config, arg string) (*model.Server, string) {
+	name, path, found := strings.Cut(arg, ":")
+	if !found {
+		return nil, ""
+	}
+	_, s := cfg.FindByName(name)
+	if s == nil {
+		return nil, ""
+	}
+	if path == "" {
+		path = "."
+	}
+	return s, path
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"sshh/internal/daemon"
+)
+
+// runDaemon implements `sshh daemon`: supervise tunnels in the foreground
+// until interrupted. The TUI and `sshh tunnel up` spawn it on demand.
+func runDaemon(args []string) int {
+	if len(args) != 0 {
+		return usage("sshh daemon")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := daemon.New(os.Stderr).Serve(ctx); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"sshh/internal/config"
+)
+
+// runDoctor implements `sshh doctor`: checks the environment and every saved
+// server and tunnel, and lists what is wrong.
+func runDoctor(args []string) int {
+	if len(args) > 0 {
+		return usage("sshh doctor")
+	}
+
+	var problems []string
+	report := func(format string, a ...any) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if _, err := exec.LookPath("ssh"); err != nil {
+		report("ssh not found in PATH")
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return fail(err)
+	}
+	if info, err := os.Stat(dir); err == nil {
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			report("%s has mode %04o; it should only be accessible to you (chmod 700 %s)", dir, perm, dir)
+		}
+	} else if !os.IsNotExist(err) {
+		report("%v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		report("%v", err)
+		cfg = &config.Config{}
+	}
+	tc, err := config.LoadTunnels()
+	if err != nil {
+		report("%v", err)
+		tc = &config.TunnelConfig{}
+	}
+
+	var keys []string
+	for _, s := range cfg.Servers {
+		for _, e := range config.ValidateServer(s, cfg.Servers) {
+			report("server %q: %v", s.Name, e)
+		}
+		keys = append(keys, s.Key)
+	}
+	for _, t := range tc.Tunnels {
+		for _, e := range config.ValidateTunnel(t, tc.Tunnels, cfg.Servers) {
+			report("tunnel %q: %v", t.Name, e)
+		}
+		keys = append(keys, t.SSHKey)
+	}
+	checked := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" || checked[key] {
+			continue
+		}
+		checked[key] = true
+		if err := config.CheckKeyMode(key); err != nil {
+			report("%v", err)
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+		return exitOK
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) == 1 {
+		fmt.Println("\n1 problem found")
+	} else {
+		fmt.Printf("\n%d problems found\n", len(problems))
+	}
+	return exitError
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/model"
+	"sshh/internal/sshexec"
+)
+
+const execUsage = "sshh exec [-c N] <selector> -- <command>"
+
+// execResult is the outcome of running the command on one server.
+type execResult struct {
+	server   string
+	code     int // -1 if ssh could not be started
+	err      error
+	duration time.Duration
+}
+
+// runExec implements `sshh exec`: run a command on every server matching the
+// selector, in parallel, streaming output prefixed with the server name.
+func runExec(args []string) int {
+	sep := -1
+	for i, a := range args {
+		if a == "--" {
+			sep = i
+			break
+		}
+	}
+	if sep == -1 || sep == len(args)-1 {
+		return usage(execUsage)
+	}
+	command := strings.Join(args[sep+1:], " ")
+
+	fs := newFlagSet("exec")
+	concurrency := fs.Int("c", 8, "maximum number of servers to run on at once")
+	selector, err := splitName(fs, args[:sep])
+	if err != nil || selector == "" || *concurrency < 1 {
+		return usage(execUsage)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	targets := selectServers(cfg.Servers, selector)
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "No servers match %q\n", selector)
+		return exitNotFound
+	}
+	return RunOn(targets, cfg.Servers, command, *concurrency)
+}
+
+// RunOn runs command on each target, at most concurrency at a time, then
+// prints a summary of exit codes. It returns the process exit code:
+// exitError if any server failed.
+func RunOn(targets, servers []model.Server, command string, concurrency int) int {
+	results := execAll(targets, servers, command, concurrency, os.Stdout, os.Stderr)
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tEXIT\tTIME")
+	failed := 0
+	for _, r := range results {
+		status := fmt.Sprint(r.code)
+		if r.code == -1 {
+			status = "error: " + r.err.Error()
+		}
+		if r.code != 0 {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.server, status, r.duration.Round(10*time.Millisecond))
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d servers failed\n", failed, len(results))
+		return exitError
+	}
+	return exitOK
+}
+
+// selectServers returns the servers matching a selector, in config order.
+// The selector is a comma-separated list of terms; each term matches a server
+// name (glob patterns allowed) or a tag. A "tag:" prefix matches tags only.
+func selectServers(servers []model.Server, selector string) []model.Server {
+	var out []model.Server
+	for _, s := range servers {
+		for _, term := range strings.Split(selector, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			if tag, ok := strings.CutPrefix(term, "tag:"); ok {
+				if hasTag(s, tag) {
+					out = append(out, s)
+					break
+				}
+				continue
+			}
+			if ok, _ := path.Match(term, s.Name); ok || hasTag(s, term) {
+				out = append(out, s)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// execAll runs command on each target with at most limit running at once.
+// Output lines are prefixed with the server name. Results are returned in
+// target order.
+func execAll(targets, servers []model.Server, command string, limit int, stdout, stderr io.Writer) []execResult {
+	width := 0
+	for _, s := range targets {
+		width = max(width, len(s.Name))
+	}
+
+	var mu sync.Mutex // serialises writes to stdout/stderr
+	results := make([]execResult, len(targets))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, s := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			prefix := fmt.Sprintf("%-*s | ", width, s.Name)
+			out := &prefixWriter{w: stdout, mu: &mu, prefix: prefix}
+			errOut := &prefixWriter{w: stderr, mu: &mu, prefix: prefix}
+			results[i] = execOne(s, servers, command, out, errOut)
+			out.Flush()
+			errOut.Flush()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func execOne(s model.Server, servers []model.Server, command string, stdout, stderr io.Writer) execResult {
+	start := time.Now()
+	res := execResult{server: s.Name}
+
+	cmd, err := sshexec.RemoteCommand(s, servers, command)
+	if err == nil {
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		err = cmd.Run()
+	}
+	res.duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		res.code = 0
+	case errors.As(err, &exitErr):
+		res.code = exitErr.ExitCode()
+	default:
+		res.code = -1
+		res.err = err
+	}
+	return res
+}
+
+// prefixWriter writes complete lines to w, each preceded by prefix.
+// Partial lines are held until a newline arrives or Flush is called.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadBytes('\n')
+		if err != nil {
+			// No newline yet: put the partial line back.
+			p.buf.Write(line)
+			return len(b), nil
+		}
+		p.emit(line)
+	}
+}
+
+// Flush writes any buffered partial line.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		p.emit(append(p.buf.Bytes(), '\n'))
+		p.buf.Reset()
+	}
+}
+
+func (p *prefixWriter) emit(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
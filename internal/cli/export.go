@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+
+	"sshh/internal/config"
+)
+
+// runExport implements `sshh export ssh-config`. Without flags it prints the
+// snippet; --sync makes config.yaml remember a file to rewrite on every save.
+func runExport(args []string) int {
+	const line = "sshh export ssh-config [-o FILE] [--sync FILE | --no-sync]"
+	if len(args) == 0 || args[0] != "ssh-config" {
+		return usage(line)
+	}
+	fs := newFlagSet("export ssh-config")
+	output := fs.String("o", "", "write to FILE instead of stdout")
+	syncTo := fs.String("sync", "", "keep FILE in sync on every save (e.g. ~/.ssh/sshh.conf)")
+	noSync := fs.Bool("no-sync", false, "stop keeping a file in sync")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || (*syncTo != "" && *noSync) {
+		return usage(line)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	tc, err := config.LoadTunnels()
+	if err != nil {
+		return fail(err)
+	}
+
+	switch {
+	case *syncTo != "":
+		// Saving writes the managed file.
+		cfg.Settings.SSHConfigSync = *syncTo
+		if err := saved(cfg.Save()); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Syncing servers and tunnels to %s\n", *syncTo)
+		return exitOK
+	case *noSync:
+		cfg.Settings.SSHConfigSync = ""
+		if err := saved(cfg.Save()); err != nil {
+			return fail(err)
+		}
+		fmt.Println("Stopped syncing ssh config")
+		return exitOK
+	case *output != "":
+		if err := cfg.WriteSSHConfig(*output, tc); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	fmt.Print(cfg.RenderSSHConfig(tc))
+	return exitOK
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/history"
+)
+
+// runHistory implements `sshh history [name]`: recent sessions from the
+// event log, newest first. A name also matches sessions logged under the
+// server's or tunnel's earlier names.
+func runHistory(args []string) int {
+	const line = "sshh history [name] [-n N] [--json]"
+	fs := newFlagSet("history")
+	limit := fs.Int("n", 20, "show at most N sessions (0 for all)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	name, err := splitName(fs, args)
+	if err != nil || *limit < 0 {
+		return usage(line)
+	}
+
+	targets, err := targetIDs(name)
+	if err != nil {
+		return fail(err)
+	}
+	events, err := history.Events()
+	if err != nil {
+		return fail(err)
+	}
+	shown := []history.Event{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if *limit > 0 && len(shown) == *limit {
+			break
+		}
+		if e := events[i]; name == "" || e.Name == name || (e.Target != "" && targets[e.Kind] == e.Target) {
+			shown = append(shown, events[i])
+		}
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, shown); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tKIND\tNAME\tCLIENT\tDURATION\tEXIT")
+	for _, e := range shown {
+		duration, exit := "-", "-"
+		if e.Ended() {
+			duration = e.Duration().Round(time.Second).String()
+		}
+		if e.Exit != nil {
+			exit = strconv.Itoa(*e.Exit)
+		}
+		client := e.Client
+		if client == "" {
+			client = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Start.Local().Format("2006-01-02 15:04:05"), e.Kind, e.Name, client, duration, exit)
+	}
+	w.Flush()
+	return exitOK
+}
+
+// targetIDs returns the IDs of the server and tunnel called name, by event
+// kind.
+func targetIDs(name string) (map[string]string, error) {
+	ids := make(map[string]string)
+	if name == "" {
+		return ids, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, s := cfg.FindByName(name); s != nil {
+		ids[history.KindServer] = s.ID
+	}
+	tc, err := config.LoadTunnels()
+	if err != nil {
+		return nil, err
+	}
+	if _, t := tc.FindTunnelByName(name); t != nil {
+		ids[history.KindTunnel] = t.ID
+	}
+	return ids, nil
+}
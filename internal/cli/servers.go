@@ -0,0 +1,453 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"sshh/internal/config"
+	"sshh/internal/model"
+	"sshh/internal/sshconfig"
+)
+
+// stringList is a repeatable string flag; values may also be comma-separated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// optionList collects repeated Key=Value flags without splitting them, since
+// ssh option values may contain commas (Ciphers) or semicolons (LocalCommand).
+type optionList []string
+
+func (l *optionList) String() string { return strings.Join(*l, "; ") }
+func (l *optionList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// parse validates the collected options.
+func (l optionList) parse() (map[string]string, error) {
+	return sshconfig.ParseOptionPairs(l)
+}
+
+// serverFlags are the fields shared by add and edit.
+type serverFlags struct {
+	name, host, user, key string
+	proxyCommand          string
+	remoteCommand, tty    string
+	preHook, postHook     string
+	group, notes          string
+	port                  int
+	tags, jump            stringList
+	options               optionList
+}
+
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.name, "name", "", "server name")
+	fs.StringVar(&f.host, "host", "", "hostname or IP")
+	fs.StringVar(&f.user, "user", "", "login user (default: ssh's, normally your local user)")
+	fs.IntVar(&f.port, "port", 22, "ssh port")
+	fs.StringVar(&f.key, "key", "", "identity file")
+	fs.Var(&f.tags, "tag", "tag (repeatable or comma-separated)")
+	fs.Var(&f.jump, "jump", "jump host (repeatable or comma-separated)")
+	fs.StringVar(&f.proxyCommand, "proxy-command", "", "ssh ProxyCommand, used when there are no jump hosts")
+	fs.Var(&f.options, "option", "ssh option as Key=Value (repeatable)")
+	fs.StringVar(&f.remoteCommand, "remote-command", "", "command to run on connect instead of a login shell")
+	fs.StringVar(&f.tty, "request-tty", "", "yes, no, force or auto")
+	fs.StringVar(&f.group, "group", "", "folder path, e.g. prod/eu")
+	fs.StringVar(&f.preHook, "pre-hook", "", "shell command run before connecting")
+	fs.StringVar(&f.postHook, "post-hook", "", "shell command run after the session ends")
+	fs.StringVar(&f.notes, "notes", "", "free-text notes")
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("sshh "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// splitName separates a leading positional name from the flags after it,
+// so both `sshh edit web --port 2222` and `sshh edit --port 2222 web` work.
+func splitName(fs *flag.FlagSet, args []string) (string, error) {
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+		return name, checkNoArgs(fs.Args()[1:])
+	}
+	return name, checkNoArgs(fs.Args())
+}
+
+func checkNoArgs(rest []string) error {
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected argument %q", rest[0])
+	}
+	return nil
+}
+
+// writeJSON prints v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// runList implements `sshh ls`.
+func runList(args []string) int {
+	fs := newFlagSet("ls")
+	asJSON := fs.Bool("json", false, "print JSON")
+	tag := fs.String("tag", "", "only servers with this tag")
+	group := fs.String("group", "", "only servers in this group or below it")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		return usage("sshh ls [--tag TAG] [--group GROUP] [--json]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+
+	servers := []model.Server{}
+	for _, s := range cfg.Servers {
+		if (*tag == "" || hasTag(s, *tag)) && inGroup(s, *group) {
+			servers = append(servers, s)
+		}
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, servers); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tUSER\tPORT\tGROUP\tTAGS")
+	for _, s := range servers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", s.Name, s.Host, s.User, s.Port, s.Group, strings.Join(s.Tags, ","))
+	}
+	w.Flush()
+	return exitOK
+}
+
+// runShow implements `sshh show <name>`.
+func runShow(args []string) int {
+	fs := newFlagSet("show")
+	asJSON := fs.Bool("json", false, "print JSON")
+	name, err := splitName(fs, args)
+	if err != nil || name == "" {
+		return usage("sshh show <name> [--json]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	_, s := cfg.FindByName(name)
+	if s == nil {
+		return notFound(name)
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, s); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+	printServer(os.Stdout, *s)
+	return exitOK
+}
+
+// runAdd implements `sshh add`.
+func runAdd(args []string) int {
+	const line = "sshh add --name NAME --host HOST [--user USER] [--port PORT] [--key KEY] [--tag TAG]... [--group GROUP] [--jump HOST]... [--proxy-command CMD] [--option K=V]... [--remote-command CMD] [--request-tty MODE] [--pre-hook CMD] [--post-hook CMD] [--notes TEXT] [--json]"
+	fs := newFlagSet("add")
+	var f serverFlags
+	f.register(fs)
+	asJSON := fs.Bool("json", false, "print the added server as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || f.name == "" || f.host == "" {
+		return usage(line)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	opts, err := f.options.parse()
+	if err != nil {
+		return fail(err)
+	}
+
+	s := model.Server{
+		ID:   model.NewID(),
+		Name: f.name,
+		Host: f.host,
+		User: f.user,
+		Port: f.port,
+		Key:  f.key,
+		Jump: f.jump,
+		Tags: f.tags,
+
+		Group: f.group,
+
+		ProxyCommand: f.proxyCommand,
+		Options:      opts,
+
+		RemoteCommand: f.remoteCommand,
+		RequestTTY:    f.tty,
+		Hooks:         model.Hooks{Pre: f.preHook, Post: f.postHook},
+
+		Notes: f.notes,
+	}
+	if err := validate(s, cfg.Servers); err != nil {
+		return fail(err)
+	}
+	if err := saved(cfg.AddServer(s)); err != nil {
+		return fail(err)
+	}
+	return report(s, *asJSON, "Added server %q\n")
+}
+
+// runEdit implements `sshh edit <name>`. Only flags that are given change.
+func runEdit(args []string) int {
+	const line = "sshh edit <name> [--name NEW] [--host HOST] [--user USER] [--port PORT] [--key KEY] [--tag TAG]... [--group GROUP] [--jump HOST]... [--proxy-command CMD] [--option K=V]... [--remote-command CMD] [--request-tty MODE] [--pre-hook CMD] [--post-hook CMD] [--notes TEXT] [--json]"
+	fs := newFlagSet("edit")
+	var f serverFlags
+	f.register(fs)
+	asJSON := fs.Bool("json", false, "print the updated server as JSON")
+	name, err := splitName(fs, args)
+	if err != nil || name == "" {
+		return usage(line)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	_, cur := cfg.FindByName(name)
+	if cur == nil {
+		return notFound(name)
+	}
+
+	opts, err := f.options.parse()
+	if err != nil {
+		return fail(err)
+	}
+
+	s := *cur
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			s.Name = f.name
+		case "host":
+			s.Host = f.host
+		case "user":
+			s.User = f.user
+		case "port":
+			s.Port = f.port
+		case "key":
+			s.Key = f.key
+		case "tag":
+			s.Tags = f.tags
+		case "jump":
+			s.Jump = f.jump
+		case "proxy-command":
+			s.ProxyCommand = f.proxyCommand
+		case "option":
+			s.Options = opts
+		case "remote-command":
+			s.RemoteCommand = f.remoteCommand
+		case "request-tty":
+			s.RequestTTY = f.tty
+		case "group":
+			s.Group = f.group
+		case "pre-hook":
+			s.Hooks.Pre = f.preHook
+		case "post-hook":
+			s.Hooks.Post = f.postHook
+		case "notes":
+			s.Notes = f.notes
+		}
+	})
+	if err := validate(s, cfg.Servers); err != nil {
+		return fail(err)
+	}
+
+	if err := saved(cfg.UpdateServer(s.ID, s)); err != nil {
+		return fail(err)
+	}
+	if s.Name != name {
+		tc, err := config.LoadTunnels()
+		if err != nil {
+			return fail(err)
+		}
+		if err := saved(tc.RenameServer(name, s.Name)); err != nil {
+			return fail(err)
+		}
+	}
+	return report(s, *asJSON, "Updated server %q\n")
+}
+
+// runRemove implements `sshh rm <name>`. Servers still used by tunnels or
+// as a jump host are only removed with --force.
+func runRemove(args []string) int {
+	fs := newFlagSet("rm")
+	force := fs.Bool("force", false, "remove even if tunnels or servers depend on it")
+	name, err := splitName(fs, args)
+	if err != nil || name == "" {
+		return usage("sshh rm <name> [--force]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+	_, s := cfg.FindByName(name)
+	if s == nil {
+		return notFound(name)
+	}
+
+	if !*force {
+		tc, err := config.LoadTunnels()
+		if err != nil {
+			return fail(err)
+		}
+		deps := append(tc.Dependents(name), cfg.JumpDependents(name)...)
+		if len(deps) > 0 {
+			return fail(fmt.Errorf("server %q is used by %s (use --force to remove anyway)",
+				name, strings.Join(deps, ", ")))
+		}
+	}
+
+	if err := saved(cfg.DeleteServer(s.ID)); err != nil {
+		return fail(err)
+	}
+	fmt.Printf("Removed server %q\n", name)
+	return exitOK
+}
+
+// validate runs config.ValidateServer on s and combines what it finds into
+// one error.
+func validate(s model.Server, servers []model.Server) error {
+	errs := config.ValidateServer(s, servers)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// report prints the result of add/edit as JSON or a one-line message.
+func report(s model.Server, asJSON bool, format string) int {
+	if asJSON {
+		if err := writeJSON(os.Stdout, s); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+	fmt.Printf(format, s.Name)
+	return exitOK
+}
+
+// printServer writes a server's fields as aligned key/value lines.
+func printServer(out io.Writer, s model.Server) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", s.Name)
+	fmt.Fprintf(w, "Host:\t%s\n", s.Host)
+	fmt.Fprintf(w, "User:\t%s\n", s.User)
+	fmt.Fprintf(w, "Port:\t%s\n", strconv.Itoa(s.Port))
+	if s.Key != "" {
+		fmt.Fprintf(w, "Key:\t%s\n", s.Key)
+	}
+	if len(s.Jump) > 0 {
+		fmt.Fprintf(w, "Jump:\t%s\n", strings.Join(s.Jump, " → "))
+	} else if s.ProxyCommand != "" {
+		fmt.Fprintf(w, "Proxy:\t%s\n", s.ProxyCommand)
+	}
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(s.Tags, ", "))
+	}
+	if s.Group != "" {
+		fmt.Fprintf(w, "Group:\t%s\n", s.Group)
+	}
+	if s.RemoteCommand != "" {
+		fmt.Fprintf(w, "Command:\t%s\n", s.RemoteCommand)
+	}
+	if s.RequestTTY != "" {
+		fmt.Fprintf(w, "TTY:\t%s\n", s.RequestTTY)
+	}
+	if s.Hooks.Pre != "" {
+		fmt.Fprintf(w, "Pre-hook:\t%s\n", s.Hooks.Pre)
+	}
+	if s.Hooks.Post != "" {
+		fmt.Fprintf(w, "Post-hook:\t%s\n", s.Hooks.Post)
+	}
+	for _, name := range sshconfig.SortedOptionNames(s.Options) {
+		fmt.Fprintf(w, "%s:\t%s\n", name, s.Options[name])
+	}
+	if s.Notes != "" {
+		fmt.Fprintf(w, "Notes:\t%s\n", s.Notes)
+	}
+	w.Flush()
+}
+
+// hasTag reports whether the server carries the given tag.
+func hasTag(s model.Server, tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// inGroup reports whether the server is in group or one of its subgroups.
+// An empty group matches every server.
+func inGroup(s model.Server, group string) bool {
+	want := model.Server{Group: group}.GroupPath()
+	have := s.GroupPath()
+	if len(have) < len(want) {
+		return false
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// notFound reports a missing server and returns exitNotFound.
+func notFound(name string) int {
+	fmt.Fprintf(os.Stderr, "Server %q not found\n", name)
+	return exitNotFound
+}
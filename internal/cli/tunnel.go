@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/daemon"
+	"sshh/internal/history"
+	"sshh/internal/sshexec"
+)
+
+const tunnelUsage = "sshh tunnel up|down|run <name> | sshh tunnel ls"
+
+// runTunnel implements `sshh tunnel`. up/down go through the daemon;
+// run holds the tunnel in the foreground until Ctrl+C.
+func runTunnel(args []string) int {
+	if len(args) == 0 {
+		return usage(tunnelUsage)
+	}
+
+	switch args[0] {
+	case "ls":
+		if len(args) != 1 {
+			return usage(tunnelUsage)
+		}
+		return tunnelList()
+	case "up", "down", "run":
+		if len(args) != 2 {
+			return usage(tunnelUsage)
+		}
+	default:
+		return usage(tunnelUsage)
+	}
+
+	name := args[1]
+	tc, err := config.LoadTunnels()
+	if err != nil {
+		return fail(err)
+	}
+	_, t := tc.FindTunnelByName(name)
+	if t == nil {
+		return fail(fmt.Errorf("tunnel %q not found", name))
+	}
+
+	switch args[0] {
+	case "up":
+		// Pre hooks run here rather than in the daemon so they can prompt.
+		cfg, err := config.Load()
+		if err != nil {
+			return fail(err)
+		}
+		resolved, err := cfg.ResolveTunnel(*t)
+		if err != nil {
+			return fail(err)
+		}
+		hooks := sshexec.Hooks{cfg.Settings.Hooks, resolved.Hooks}
+		if err := hooks.RunPre(sshexec.TunnelEnv(resolved), os.Stdin, os.Stdout, os.Stderr); err != nil {
+			return fail(err)
+		}
+		if err := daemon.Start(name, history.ClientCLI); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Tunnel %q started\n", name)
+	case "down":
+		if err := daemon.Stop(name); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Tunnel %q stopped\n", name)
+	case "run":
+		cfg, err := config.Load()
+		if err != nil {
+			return fail(err)
+		}
+		resolved, err := cfg.ResolveTunnel(*t)
+		if err != nil {
+			return fail(err)
+		}
+		var ev history.Event
+		err = sshexec.RunTunnel(resolved, cfg.Servers, cfg.Settings.Hooks, func() {
+			ev, _ = history.Begin(history.KindTunnel, t.ID, name, history.ClientCLI)
+		})
+		_ = history.Finish(ev, sshexec.ExitCode(err))
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return exitOK
+}
+
+// tunnelList prints the tunnels the daemon is supervising.
+func tunnelList() int {
+	statuses, err := daemon.List()
+	if err == daemon.ErrNotRunning {
+		fmt.Println("No tunnels running (daemon not started)")
+		return exitOK
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No tunnels running")
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPID\tSINCE\tERROR")
+	for _, st := range statuses {
+		pid := "-"
+		if st.PID != 0 {
+			pid = fmt.Sprint(st.PID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			st.Name, st.State, pid, st.Since.Format(time.DateTime), st.Error)
+	}
+	w.Flush()
+	return exitOK
+}
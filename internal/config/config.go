@@ -1,17 +1,64 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 
 	"sshh/internal/model"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Config holds the list of saved servers.
+// Config holds the list of saved servers and global settings.
 type Config struct {
-	Servers []model.Server `yaml:"servers"`
+	Version  int            `yaml:"version"`
+	Settings Settings       `yaml:"settings,omitempty"`
+	Servers  []model.Server `yaml:"servers"`
+
+	base []byte // file contents as last loaded or saved, for merging
+}
+
+// Settings holds global preferences.
+type Settings struct {
+	// SSHConfigSync is an ssh_config file rewritten from the servers and
+	// tunnels on every save, for other tools to Include.
+	SSHConfigSync string `yaml:"ssh_config_sync,omitempty"`
+
+	// Hooks run around every connection and tunnel, outside any
+	// server or tunnel hooks.
+	Hooks model.Hooks `yaml:"hooks,omitempty"`
+
+	// SSHAsChild keeps sshh running as ssh's parent instead of handing the
+	// process over, so the end of each session and its exit code are
+	// recorded in the event log.
+	SSHAsChild bool `yaml:"ssh_as_child,omitempty"`
+
+	// Sort orders the server list: frecency (the default), mru,
+	// alphabetical or config.
+	Sort string `yaml:"sort,omitempty"`
+}
+
+// configSchema is the version history of config.yaml.
+var configSchema = Schema{
+	File: "config.yaml",
+	Steps: []Migration{
+		// 2: servers have IDs.
+		func(doc Document) error {
+			legacyIDs(doc.List("servers"))
+			return nil
+		},
+	},
+	Rewrite: func(data []byte) ([]byte, error) {
+		cfg, err := parseConfig(data)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(cfg)
+	},
 }
 
 // Dir returns the config directory path (~/.sshh/).
@@ -39,65 +86,209 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(p)
+	data, err := readFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = Upgrade(configSchema, p, data); err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(data)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &Config{}, nil
-		}
 		return nil, err
 	}
+	cfg.base = data
+	return cfg, nil
+}
 
+// parseConfig decodes config.yaml in the current version; no data is an
+// empty config. Servers added by hand without an ID get their legacy one.
+func parseConfig(data []byte) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	// A server without a port uses ssh's default. An explicit port: 0 is
+	// kept for validation to report.
+	var ports struct {
+		Servers []struct {
+			Port *int `yaml:"port"`
+		} `yaml:"servers"`
+	}
+	if err := yaml.Unmarshal(data, &ports); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int)
+	for i := range cfg.Servers {
+		s := &cfg.Servers[i]
+		if s.ID == "" {
+			s.ID = model.LegacyID(s.Name, seen[s.Name])
+			seen[s.Name]++
+		}
+		if ports.Servers[i].Port == nil {
+			s.Port = 22
+		}
+	}
 	return &cfg, nil
 }
 
 // Save writes the config to disk, creating the directory if needed.
+// Changes another sshh saved since this config was loaded are merged in
+// first, and once the file is written c is updated to the merged result.
+// If both changed the same server, this version is kept and a
+// *ConflictError is returned after saving.
 func (c *Config) Save() error {
-	dir, err := Dir()
+	return c.save(c.Servers)
+}
+
+// save is Save with servers in place of c.Servers. c is left as it was if
+// the save fails, so a change that can't be saved doesn't linger to fail
+// every later save too.
+func (c *Config) save(servers []model.Server) error {
+	p, err := filePath()
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(dir, 0700); err != nil {
+
+	unlock, err := Lock()
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	p, err := filePath()
+	merged, conflicts, err := c.mergeFromDisk(p, servers)
 	if err != nil {
 		return err
 	}
+	if err := uniqueNames("server", merged.Servers, func(s model.Server) string { return s.Name }); err != nil {
+		return err
+	}
 
-	data, err := yaml.Marshal(c)
+	merged.Version = configSchema.Current()
+	data, err := yaml.Marshal(merged)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0600)
+	if err := WriteFileAtomic(p, data, 0600); err != nil {
+		return err
+	}
+	merged.base = data
+	*c = *merged
+
+	if c.Settings.SSHConfigSync != "" {
+		tc, err := LoadTunnels()
+		if err != nil {
+			return err
+		}
+		if err := syncSSHConfig(c, tc); err != nil {
+			return err
+		}
+	}
+	return conflictErr("config.yaml", conflicts)
 }
 
-// AddServer appends a server and saves.
+// mergeFromDisk returns a copy of c with servers in place of c.Servers and
+// the changes saved to p since c was loaded folded in, and the names of
+// servers (or "settings") changed on both sides.
+func (c *Config) mergeFromDisk(p string, servers []model.Server) (*Config, []string, error) {
+	merged := *c
+	merged.Servers = servers
+	disk, err := readFile(p)
+	if err != nil || bytes.Equal(disk, c.base) {
+		return &merged, nil, err
+	}
+	base, err := parseConfig(c.base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if disk, err = Upgrade(configSchema, p, disk); err != nil {
+		return nil, nil, err
+	}
+	theirs, err := parseConfig(disk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	var conflicts []string
+	merged.Servers, conflicts = merge(base.Servers, servers, theirs.Servers,
+		func(s model.Server) string { return s.ID },
+		func(s model.Server) string { return s.Name })
+	switch {
+	case reflect.DeepEqual(c.Settings, base.Settings):
+		merged.Settings = theirs.Settings
+	case !reflect.DeepEqual(theirs.Settings, base.Settings) && !reflect.DeepEqual(theirs.Settings, c.Settings):
+		conflicts = append(conflicts, "settings")
+	}
+	return &merged, conflicts, nil
+}
+
+// AddServer appends a server, giving it an ID if it has none, and saves.
 func (c *Config) AddServer(s model.Server) error {
-	c.Servers = append(c.Servers, s)
-	return c.Save()
+	if s.ID == "" {
+		s.ID = model.NewID()
+	}
+	return c.save(append(slices.Clip(c.Servers), s))
 }
 
-// UpdateServer replaces the server at index i and saves.
-func (c *Config) UpdateServer(i int, s model.Server) error {
-	if i < 0 || i >= len(c.Servers) {
+// UpdateServer replaces the server with the given ID and saves. If the
+// server was renamed, other servers using it as a jump host are updated to
+// match.
+func (c *Config) UpdateServer(id string, s model.Server) error {
+	cur := c.FindByID(id)
+	if cur == nil {
 		return nil
 	}
-	c.Servers[i] = s
-	return c.Save()
+	servers := slices.Clone(c.Servers)
+	for j := range servers {
+		if servers[j].ID == id {
+			s.ID = id
+			servers[j] = s
+		}
+		if cur.Name != s.Name && contains(servers[j].Jump, cur.Name) {
+			servers[j].Jump = slices.Clone(servers[j].Jump)
+			replaceAll(servers[j].Jump, cur.Name, s.Name)
+		}
+	}
+	return c.save(servers)
 }
 
-// DeleteServer removes the server at index i and saves.
-func (c *Config) DeleteServer(i int) error {
-	if i < 0 || i >= len(c.Servers) {
-		return nil
+// EditServers calls edit on a copy of each server with one of the given IDs
+// and saves once. edit must not modify the slices in the server it is given
+// in place; replace them instead.
+func (c *Config) EditServers(ids []string, edit func(*model.Server)) error {
+	servers := slices.Clone(c.Servers)
+	for i := range servers {
+		if contains(ids, servers[i].ID) {
+			edit(&servers[i])
+		}
+	}
+	return c.save(servers)
+}
+
+// DeleteServer removes the server with the given ID and saves.
+func (c *Config) DeleteServer(id string) error {
+	return c.DeleteServers([]string{id})
+}
+
+// DeleteServers removes the servers with the given IDs and saves once.
+func (c *Config) DeleteServers(ids []string) error {
+	var kept []model.Server
+	for _, s := range c.Servers {
+		if !contains(ids, s.ID) {
+			kept = append(kept, s)
+		}
+	}
+	return c.save(kept)
+}
+
+// FindByID returns the server with the given ID, or nil if not found.
+func (c *Config) FindByID(id string) *model.Server {
+	for i := range c.Servers {
+		if c.Servers[i].ID == id {
+			return &c.Servers[i]
+		}
 	}
-	c.Servers = append(c.Servers[:i], c.Servers[i+1:]...)
-	return c.Save()
+	return nil
 }
 
 // FindByName returns the index and server with the given name, or -1 if not found.
@@ -110,3 +301,50 @@ func (c *Config) FindByName(name string) (int, *model.Server) {
 	return -1, nil
 }
 
+// JumpDependents returns the names of servers that use the named server
+// as a jump host.
+func (c *Config) JumpDependents(name string) []string {
+	var names []string
+	for _, s := range c.Servers {
+		if contains(s.Jump, name) {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// contains reports whether list has an entry equal to s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceAll replaces entries equal to old with new in place and reports
+// whether any were found.
+func replaceAll(list []string, old, new string) bool {
+	found := false
+	for i := range list {
+		if list[i] == old {
+			list[i] = new
+			found = true
+		}
+	}
+	return found
+}
+
+// OpenLog opens the named log file in the config directory for appending,
+// creating the directory and file if needed.
+func OpenLog(name string) (*os.File, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
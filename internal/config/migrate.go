@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"sshh/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a decoded config file as generic maps and lists, which
+// migrations rewrite in place.
+type Document map[string]any
+
+// Migration upgrades a document by one version.
+type Migration func(doc Document) error
+
+// Schema is the version history of one file in the config directory. Files
+// without a version key are version 1, the format before versioning.
+type Schema struct {
+	File  string      // base name, e.g. config.yaml
+	Steps []Migration // Steps[i] upgrades version i+1 to i+2
+
+	// Rewrite re-encodes a migrated file the way a save would, so it reads
+	// naturally; nil keeps the generic encoding.
+	Rewrite func(data []byte) ([]byte, error)
+}
+
+// Current returns the version this sshh writes.
+func (s Schema) Current() int {
+	return len(s.Steps) + 1
+}
+
+// VersionError is returned for a file written by a newer sshh.
+type VersionError struct {
+	File    string
+	Version int // version of the file
+	Current int // newest version this sshh understands
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s is version %d, but this sshh only understands up to version %d; it was written by a newer sshh, so upgrade sshh to use it",
+		e.File, e.Version, e.Current)
+}
+
+// Upgrade brings data, the contents of the file at path, up to the current
+// version of s, one step at a time. When it had to, the original is first
+// copied to path.v<N>.bak and the upgraded contents are written back, unless
+// another save holds the lock, in which case the next save writes them.
+// Data that is already current, or empty, is returned as is.
+func Upgrade(s Schema, path string, data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+	// Decoded into a plain map: yaml.v3 would give nested mappings the
+	// Document type too.
+	isJSON := filepath.Ext(path) == ".json"
+	var m map[string]any
+	var err error
+	if isJSON {
+		err = json.Unmarshal(data, &m)
+	} else {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m == nil {
+		return data, nil // e.g. only comments
+	}
+	doc := Document(m)
+
+	from, err := doc.version()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case from > s.Current():
+		return nil, &VersionError{File: s.File, Version: from, Current: s.Current()}
+	case from == s.Current():
+		return data, nil
+	}
+	for v := from; v < s.Current(); v++ {
+		if err := s.Steps[v-1](doc); err != nil {
+			return nil, fmt.Errorf("%s: upgrading from version %d: %w", path, v, err)
+		}
+	}
+	doc["version"] = s.Current()
+
+	var out []byte
+	if isJSON {
+		out, err = json.MarshalIndent(doc, "", "  ")
+	} else {
+		out, err = yaml.Marshal(doc)
+	}
+	if err == nil && s.Rewrite != nil {
+		out, err = s.Rewrite(out)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, writeUpgraded(path, data, out, from)
+}
+
+// writeUpgraded copies old, the version from contents of path, to a backup
+// and replaces them with upgraded if nobody changed the file meanwhile.
+func writeUpgraded(path string, old, upgraded []byte, from int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := WriteFileAtomic(backup, old, 0600); err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+
+	unlock, ok, err := tryLock()
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+	cur, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(cur, old) {
+		return nil // changed since it was read; whoever did it upgraded it
+	}
+	return WriteFileAtomic(path, upgraded, 0600)
+}
+
+// version returns the document's version key, 1 if there is none.
+func (d Document) version() (int, error) {
+	switch v := d["version"].(type) {
+	case nil:
+		return 1, nil
+	case int:
+		if v >= 1 {
+			return v, nil
+		}
+	case float64: // from JSON
+		if v >= 1 && v == math.Trunc(v) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid version %v", d["version"])
+}
+
+// List returns the list under key, or nil if there is none.
+func (d Document) List(key string) []any {
+	list, _ := d[key].([]any)
+	return list
+}
+
+// Map returns the mapping under key, or nil if there is none.
+func (d Document) Map(key string) map[string]any {
+	m, _ := d[key].(map[string]any)
+	return m
+}
+
+// legacyIDs gives each entry in list without an id the one it would get from
+// model.LegacyID when loaded.
+func legacyIDs(list []any) {
+	seen := make(map[string]int)
+	for _, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if id, _ := entry["id"].(string); id != "" {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		entry["id"] = model.LegacyID(name, seen[name])
+		seen[name]++
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sshh/internal/model"
+	"sshh/internal/testutil"
+)
+
+// checkUpgraded checks that file in dir was backed up as file.v<from>.bak with
+// the fixture's contents and rewritten with wantVersion.
+func checkUpgraded(t *testing.T, dir, fixture, file string, from int, wantVersion string) {
+	t.Helper()
+	orig, err := os.ReadFile(filepath.Join("testdata", fixture, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%s.v%d.bak", file, from)))
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if string(backup) != string(orig) {
+		t.Errorf("backup = %q, want the original %q", backup, orig)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), wantVersion) {
+		t.Errorf("%s was not rewritten with %q:\n%s", file, wantVersion, data)
+	}
+}
+
+func TestUpgradeConfigV1(t *testing.T) {
+	dir := testutil.InstallFixture(t, filepath.Join("testdata", "v1"))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.Server{
+		{ID: model.LegacyID("web", 0), Name: "web", Host: "10.0.0.1", User: "deploy", Port: 2222},
+		{ID: model.LegacyID("db", 0), Name: "db", Host: "10.0.0.2", User: "postgres", Port: 22},
+	}
+	if !reflect.DeepEqual(cfg.Servers, want) {
+		t.Errorf("servers = %+v, want %+v", cfg.Servers, want)
+	}
+	if cfg.Version != configSchema.Current() {
+		t.Errorf("version = %d, want %d", cfg.Version, configSchema.Current())
+	}
+	checkUpgraded(t, dir, "v1", "config.yaml", 1, "version: 2")
+
+	// Loading again gives the same IDs from the rewritten file.
+	again, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Servers, want) {
+		t.Errorf("after rewrite, servers = %+v, want %+v", again.Servers, want)
+	}
+}
+
+func TestUpgradeTunnelsV1(t *testing.T) {
+	dir := testutil.InstallFixture(t, filepath.Join("testdata", "v1"))
+	tc, err := LoadTunnels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.Tunnel{
+		{
+			ID: model.LegacyID("pg", 0), Name: "pg", Server: "db",
+			Forwards: []model.Forward{{Type: model.TunnelLocal, LocalPort: 5433, RemoteHost: "localhost", RemotePort: 5432}},
+		},
+		{
+			ID: model.LegacyID("socks", 0), Name: "socks", SSHHost: "10.0.0.3",
+			Forwards: []model.Forward{{Type: model.TunnelDynamic, LocalPort: 1080}},
+		},
+	}
+	if !reflect.DeepEqual(tc.Tunnels, want) {
+		t.Errorf("tunnels = %+v, want %+v", tc.Tunnels, want)
+	}
+	checkUpgraded(t, dir, "v1", "tunnels.yaml", 1, "version: 2")
+}
+
+func TestUpgradeNewerVersion(t *testing.T) {
+	dir := testutil.InstallFixture(t, filepath.Join("testdata", "v1"))
+	p := filepath.Join(dir, "config.yaml")
+	data := []byte("version: 99\nservers: []\n")
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load()
+	var verr *VersionError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load() error = %v, want a *VersionError", err)
+	}
+	if verr.Version != 99 || verr.Current != configSchema.Current() {
+		t.Errorf("VersionError = %+v", verr)
+	}
+	if got, _ := os.ReadFile(p); string(got) != string(data) {
+		t.Errorf("file changed to %q", got)
+	}
+	if _, err := os.Stat(p + ".v99.bak"); !os.IsNotExist(err) {
+		t.Errorf("backup written for a newer file: %v", err)
+	}
+}
+
+func TestUpgradeCurrent(t *testing.T) {
+	dir := testutil.InstallFixture(t, filepath.Join("testdata", "v1"))
+	p := filepath.Join(dir, "config.yaml")
+	data := []byte("version: 2\nservers:\n  - id: abc\n    name: web\n    host: h\n")
+
+	got, err := Upgrade(configSchema, p, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("Upgrade changed a current file to %q", got)
+	}
+	if _, err := os.Stat(p + ".v2.bak"); !os.IsNotExist(err) {
+		t.Errorf("backup written for a current file: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sshh/internal/model"
+	"sshh/internal/sshconfig"
+
+	"gopkg.in/yaml.v3"
+)
+
+// managedHeader opens the ssh_config file kept in sync by sshh.
+const managedHeader = "# Managed by sshh; changes here are overwritten on every save.\n" +
+	"# Add `Include %s` near the top of ~/.ssh/config to use it.\n\n"
+
+// RenderSSHConfig renders the servers and tunnels as an ssh_config snippet.
+// Tunnels whose server no longer exists are left out.
+func (c *Config) RenderSSHConfig(tc *TunnelConfig) string {
+	var tunnels []model.Tunnel
+	for _, t := range tc.Tunnels {
+		if resolved, err := c.ResolveTunnel(t); err == nil {
+			tunnels = append(tunnels, resolved)
+		}
+	}
+	return sshconfig.Render(c.Servers, tunnels)
+}
+
+// WriteSSHConfig writes the rendered servers and tunnels to path, replacing
+// the file.
+func (c *Config) WriteSSHConfig(path string, tc *TunnelConfig) error {
+	return writeSSHConfig(sshconfig.ExpandTilde(path), c.RenderSSHConfig(tc))
+}
+
+// syncSSHConfig rewrites the managed ssh_config file, if one is configured.
+func syncSSHConfig(c *Config, tc *TunnelConfig) error {
+	path := c.Settings.SSHConfigSync
+	if path == "" {
+		return nil
+	}
+	path = sshconfig.ExpandTilde(path)
+	data := fmt.Sprintf(managedHeader, path) + c.RenderSSHConfig(tc)
+	if err := writeSSHConfig(path, data); err != nil {
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	return nil
+}
+
+// syncSetting reads only Settings.SSHConfigSync from config.yaml, so that
+// saving tunnels doesn't depend on the servers in it parsing.
+func syncSetting() (string, error) {
+	p, err := filePath()
+	if err != nil {
+		return "", err
+	}
+	data, err := readFile(p)
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		Settings struct {
+			SSHConfigSync string `yaml:"ssh_config_sync"`
+		} `yaml:"settings"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%s: %w", p, err)
+	}
+	return doc.Settings.SSHConfigSync, nil
+}
+
+// writeSSHConfig writes data to path, creating its directory if needed. The
+// file is replaced in one step since ~/.ssh/config may Include it.
+func writeSSHConfig(path, data string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, []byte(data), 0600)
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// ConflictError is returned by a save that went through but replaced changes
+// another sshh made to the same entries since this one loaded the file.
+type ConflictError struct {
+	File  string   // base name of the file, e.g. config.yaml
+	Names []string // entries changed on both sides; this side's version was kept
+}
+
+func (e *ConflictError) Error() string {
+	quoted := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("%s was also changed elsewhere; kept this version of %s", e.File, strings.Join(quoted, ", "))
+}
+
+// conflictErr returns a *ConflictError for names, or nil if there are none.
+func conflictErr(file string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return &ConflictError{File: file, Names: names}
+}
+
+// Lock takes an exclusive advisory lock on the config directory, creating it
+// if needed, and returns a function that releases it. Saves hold it from
+// re-reading a file until the new version is in place, so concurrent TUIs
+// and CLI commands don't lose each other's changes. It must not be taken
+// twice by the same process.
+func Lock() (func(), error) {
+	unlock, _, err := lock(syscall.LOCK_EX)
+	return unlock, err
+}
+
+// tryLock is Lock without waiting. It reports false if the lock is held,
+// including by this process.
+func tryLock() (func(), bool, error) {
+	return lock(syscall.LOCK_EX | syscall.LOCK_NB)
+}
+
+func lock(how int) (func(), bool, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, false, err
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("locking %s: %w", dir, err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, true, nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers see either the old or the new contents.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// readFile returns the contents of path, or nil if it doesn't exist.
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// merge combines this side's entries (mine) with the ones on disk (theirs),
+// both derived from base, matching entries by key. Entries only one side
+// changed, added or removed take that side's version. Entries both sides
+// changed differently keep mine and are reported as conflicts, by name.
+// Mine's order is kept, with entries added elsewhere at the end.
+func merge[T any](base, mine, theirs []T, key, name func(T) string) ([]T, []string) {
+	index := func(list []T) map[string]T {
+		m := make(map[string]T, len(list))
+		for _, v := range list {
+			m[key(v)] = v
+		}
+		return m
+	}
+	baseBy, mineBy, theirsBy := index(base), index(mine), index(theirs)
+
+	var merged []T
+	var conflicts []string
+	for _, m := range mine {
+		k := key(m)
+		b, inBase := baseBy[k]
+		t, inTheirs := theirsBy[k]
+		changed := !inBase || !reflect.DeepEqual(m, b)
+		switch {
+		case !inTheirs && inBase:
+			// Removed elsewhere: gone unless it was edited here too.
+			if changed {
+				conflicts = append(conflicts, name(m))
+				merged = append(merged, m)
+			}
+		case !changed:
+			merged = append(merged, t)
+		default:
+			if inTheirs && !reflect.DeepEqual(t, m) && (!inBase || !reflect.DeepEqual(t, b)) {
+				conflicts = append(conflicts, name(m))
+			}
+			merged = append(merged, m)
+		}
+	}
+	for _, t := range theirs {
+		k := key(t)
+		if _, ok := mineBy[k]; ok {
+			continue
+		}
+		b, inBase := baseBy[k]
+		switch {
+		case !inBase:
+			merged = append(merged, t) // added elsewhere
+		case !reflect.DeepEqual(t, b):
+			conflicts = append(conflicts, name(t)) // removed here, edited elsewhere
+		}
+	}
+	return merged, conflicts
+}
+
+// uniqueNames returns an error if two entries share a name. Names are how
+// servers and tunnels are referred to on the command line and in jump hosts,
+// so they must not be ambiguous.
+func uniqueNames[T any](kind string, list []T, name func(T) string) error {
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		n := name(v)
+		if seen[n] {
+			return fmt.Errorf("%s name %q is used more than once", kind, n)
+		}
+		seen[n] = true
+	}
+	return nil
+}
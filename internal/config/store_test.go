@@ -0,0 +1,269 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"sshh/internal/model"
+)
+
+func srv(id, name, host string) model.Server {
+	return model.Server{ID: id, Name: name, Host: host}
+}
+
+func TestMerge(t *testing.T) {
+	base := []model.Server{srv("1", "web", "w"), srv("2", "db", "d")}
+	tests := []struct {
+		name      string
+		mine      []model.Server
+		theirs    []model.Server
+		want      []model.Server
+		conflicts []string
+	}{
+		{
+			name:   "unchanged",
+			mine:   base,
+			theirs: base,
+			want:   base,
+		},
+		{
+			name:   "added here",
+			mine:   append(slices.Clone(base), srv("3", "new", "n")),
+			theirs: base,
+			want:   []model.Server{srv("1", "web", "w"), srv("2", "db", "d"), srv("3", "new", "n")},
+		},
+		{
+			name:   "added elsewhere goes last",
+			mine:   []model.Server{srv("2", "db", "d"), srv("1", "web", "w")},
+			theirs: append(slices.Clone(base), srv("3", "new", "n")),
+			want:   []model.Server{srv("2", "db", "d"), srv("1", "web", "w"), srv("3", "new", "n")},
+		},
+		{
+			name:   "deleted here",
+			mine:   []model.Server{srv("2", "db", "d")},
+			theirs: base,
+			want:   []model.Server{srv("2", "db", "d")},
+		},
+		{
+			name:   "deleted elsewhere",
+			mine:   base,
+			theirs: []model.Server{srv("1", "web", "w")},
+			want:   []model.Server{srv("1", "web", "w")},
+		},
+		{
+			name:   "edited elsewhere",
+			mine:   base,
+			theirs: []model.Server{srv("1", "web", "w2"), srv("2", "db", "d")},
+			want:   []model.Server{srv("1", "web", "w2"), srv("2", "db", "d")},
+		},
+		{
+			name:   "different entries edited on each side",
+			mine:   []model.Server{srv("1", "web", "mine"), srv("2", "db", "d")},
+			theirs: []model.Server{srv("1", "web", "w"), srv("2", "db", "theirs")},
+			want:   []model.Server{srv("1", "web", "mine"), srv("2", "db", "theirs")},
+		},
+		{
+			name:   "same edit on both sides",
+			mine:   []model.Server{srv("1", "web", "same"), srv("2", "db", "d")},
+			theirs: []model.Server{srv("1", "web", "same"), srv("2", "db", "d")},
+			want:   []model.Server{srv("1", "web", "same"), srv("2", "db", "d")},
+		},
+		{
+			name:      "edited on both sides",
+			mine:      []model.Server{srv("1", "web", "mine"), srv("2", "db", "d")},
+			theirs:    []model.Server{srv("1", "web", "theirs"), srv("2", "db", "d")},
+			want:      []model.Server{srv("1", "web", "mine"), srv("2", "db", "d")},
+			conflicts: []string{"web"},
+		},
+		{
+			name:      "deleted here, edited elsewhere",
+			mine:      []model.Server{srv("2", "db", "d")},
+			theirs:    []model.Server{srv("1", "web", "theirs"), srv("2", "db", "d")},
+			want:      []model.Server{srv("2", "db", "d")},
+			conflicts: []string{"web"},
+		},
+		{
+			name:      "edited here, deleted elsewhere",
+			mine:      []model.Server{srv("1", "web", "mine"), srv("2", "db", "d")},
+			theirs:    []model.Server{srv("2", "db", "d")},
+			want:      []model.Server{srv("1", "web", "mine"), srv("2", "db", "d")},
+			conflicts: []string{"web"},
+		},
+		{
+			name:   "renamed here",
+			mine:   []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+			theirs: base,
+			want:   []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+		},
+		{
+			name:   "renamed elsewhere",
+			mine:   base,
+			theirs: []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+			want:   []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+		},
+		{
+			name:      "renamed here, edited elsewhere",
+			mine:      []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+			theirs:    []model.Server{srv("1", "web", "w2"), srv("2", "db", "d")},
+			want:      []model.Server{srv("1", "www", "w"), srv("2", "db", "d")},
+			conflicts: []string{"www"},
+		},
+		{
+			name:   "same name added on both sides",
+			mine:   append(slices.Clone(base), srv("3", "new", "mine")),
+			theirs: append(slices.Clone(base), srv("4", "new", "theirs")),
+			want: []model.Server{
+				srv("1", "web", "w"), srv("2", "db", "d"), srv("3", "new", "mine"), srv("4", "new", "theirs"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := merge(base, tt.mine, tt.theirs,
+				func(s model.Server) string { return s.ID },
+				func(s model.Server) string { return s.Name })
+			if !slices.EqualFunc(got, tt.want, func(a, b model.Server) bool {
+				return a.ID == b.ID && a.Name == b.Name && a.Host == b.Host
+			}) {
+				t.Errorf("merged = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %q, want %q", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	name := func(s model.Server) string { return s.Name }
+	if err := uniqueNames("server", []model.Server{srv("1", "a", ""), srv("2", "b", "")}, name); err != nil {
+		t.Errorf("distinct names: %v", err)
+	}
+	if err := uniqueNames("server", []model.Server{srv("1", "a", ""), srv("2", "a", "")}, name); err == nil {
+		t.Error("duplicate names: no error")
+	}
+}
+
+func TestSaveDuplicateLeavesConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	a, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.AddServer(srv("", "x", "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddServer(srv("", "x", "b")); err == nil {
+		t.Fatal("adding a name added elsewhere: no error")
+	}
+	if len(b.Servers) != 0 {
+		t.Errorf("after the failed save, b.Servers = %v, want none", b.Servers)
+	}
+	if err := b.AddServer(srv("", "y", "b")); err != nil {
+		t.Fatalf("next save: %v", err)
+	}
+
+	disk, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range disk.Servers {
+		names = append(names, s.Name)
+	}
+	if want := []string{"y", "x"}; !slices.Equal(names, want) {
+		t.Errorf("saved servers = %q, want %q", names, want)
+	}
+}
+
+func TestEditServersFailedSave(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddServer(model.Server{Name: "web", Host: "w", Tags: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{cfg.Servers[0].ID}
+	addTag := func(s *model.Server) { s.Tags = append(slices.Clip(s.Tags), "b") }
+
+	// A hand edit that broke the file makes the save fail.
+	p := filepath.Join(home, ".sshh", "config.yaml")
+	orig, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte("servers: {"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.EditServers(ids, addTag); err == nil {
+		t.Fatal("saving over a broken file: no error")
+	}
+	if got := cfg.Servers[0].Tags; !slices.Equal(got, []string{"a"}) {
+		t.Errorf("after the failed save, tags = %q, want [a]", got)
+	}
+
+	if err := os.WriteFile(p, orig, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.EditServers(ids, addTag); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Servers[0].Tags; !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("tags = %q, want [a b]", got)
+	}
+}
+
+func TestSaveTunnelsSync(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".sshh")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(dir, "config.yaml")
+	tunnel := model.Tunnel{
+		Name: "socks", SSHHost: "10.0.0.3",
+		Forwards: []model.Forward{{Type: model.TunnelDynamic, LocalPort: 1080}},
+	}
+
+	// Without a sync file, the servers in config.yaml aren't read.
+	if err := os.WriteFile(cfgPath, []byte("version: 2\nservers: broken\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tc, err := LoadTunnels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.AddTunnel(tunnel); err != nil {
+		t.Errorf("saving tunnels next to a broken config.yaml: %v", err)
+	}
+
+	sync := filepath.Join(home, "sshh.conf")
+	data := "version: 2\nsettings:\n  ssh_config_sync: " + sync + "\nservers: []\n"
+	if err := os.WriteFile(cfgPath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tunnel.Name = "socks2"
+	tunnel.Forwards[0].LocalPort = 1081
+	if err := tc.AddTunnel(tunnel); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "Host socks2\n") {
+		t.Errorf("sync file is missing the new tunnel:\n%s", got)
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
-	"os"
+	"bytes"
+	"fmt"
 	"path/filepath"
+	"slices"
 
 	"sshh/internal/model"
 
@@ -11,7 +13,47 @@ import (
 
 // TunnelConfig holds the list of saved tunnel templates.
 type TunnelConfig struct {
+	Version int            `yaml:"version"`
 	Tunnels []model.Tunnel `yaml:"tunnels"`
+
+	base []byte // file contents as last loaded or saved, for merging
+}
+
+// tunnelSchema is the version history of tunnels.yaml.
+var tunnelSchema = Schema{
+	File: "tunnels.yaml",
+	Steps: []Migration{
+		// 2: tunnels have IDs and a list of forwards instead of a single
+		// one in top-level type/local_port/remote_host/remote_port keys.
+		func(doc Document) error {
+			tunnels := doc.List("tunnels")
+			for _, item := range tunnels {
+				t, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				fwd := make(map[string]any)
+				for _, key := range []string{"type", "local_port", "remote_host", "remote_port"} {
+					if v, ok := t[key]; ok {
+						fwd[key] = v
+						delete(t, key)
+					}
+				}
+				if _, ok := t["forwards"]; !ok && fwd["type"] != nil {
+					t["forwards"] = []any{fwd}
+				}
+			}
+			legacyIDs(tunnels)
+			return nil
+		},
+	},
+	Rewrite: func(data []byte) ([]byte, error) {
+		tc, err := parseTunnels(data)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(tc)
+	},
 }
 
 // tunnelFilePath returns the full path to tunnels.yaml.
@@ -31,65 +73,152 @@ func LoadTunnels() (*TunnelConfig, error) {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(p)
+	data, err := readFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = Upgrade(tunnelSchema, p, data); err != nil {
+		return nil, err
+	}
+	tc, err := parseTunnels(data)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &TunnelConfig{}, nil
-		}
 		return nil, err
 	}
+	tc.base = data
+	return tc, nil
+}
 
+// parseTunnels decodes tunnels.yaml in the current version; no data is an
+// empty config. Tunnels added by hand without an ID get their legacy one.
+func parseTunnels(data []byte) (*TunnelConfig, error) {
 	var tc TunnelConfig
 	if err := yaml.Unmarshal(data, &tc); err != nil {
 		return nil, err
 	}
+	seen := make(map[string]int)
+	for i := range tc.Tunnels {
+		if t := &tc.Tunnels[i]; t.ID == "" {
+			t.ID = model.LegacyID(t.Name, seen[t.Name])
+			seen[t.Name]++
+		}
+	}
 	return &tc, nil
 }
 
-// Save writes the tunnel config to ~/.sshh/tunnels.yaml.
+// Save writes the tunnel config to ~/.sshh/tunnels.yaml, merging in changes
+// saved elsewhere first like Config.Save.
 func (tc *TunnelConfig) Save() error {
-	dir, err := Dir()
+	return tc.save(tc.Tunnels)
+}
+
+// save is Save with tunnels in place of tc.Tunnels; like Config.save, it
+// leaves tc as it was if the save fails.
+func (tc *TunnelConfig) save(tunnels []model.Tunnel) error {
+	p, err := tunnelFilePath()
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(dir, 0700); err != nil {
+
+	unlock, err := Lock()
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	p, err := tunnelFilePath()
+	merged, conflicts, err := tc.mergeFromDisk(p, tunnels)
 	if err != nil {
 		return err
 	}
+	if err := uniqueNames("tunnel", merged.Tunnels, func(t model.Tunnel) string { return t.Name }); err != nil {
+		return err
+	}
+
+	merged.Version = tunnelSchema.Current()
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(p, data, 0600); err != nil {
+		return err
+	}
+	merged.base = data
+	*tc = *merged
 
-	data, err := yaml.Marshal(tc)
+	// The rest of config.yaml is only needed to sync the ssh_config file.
+	sync, err := syncSetting()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0600)
+	if sync != "" {
+		cfg, err := Load()
+		if err != nil {
+			return err
+		}
+		if err := syncSSHConfig(cfg, tc); err != nil {
+			return err
+		}
+	}
+	return conflictErr("tunnels.yaml", conflicts)
+}
+
+// mergeFromDisk returns a copy of tc with tunnels in place of tc.Tunnels and
+// the changes saved to p since tc was loaded folded in, and the names of
+// tunnels changed on both sides.
+func (tc *TunnelConfig) mergeFromDisk(p string, tunnels []model.Tunnel) (*TunnelConfig, []string, error) {
+	merged := *tc
+	merged.Tunnels = tunnels
+	disk, err := readFile(p)
+	if err != nil || bytes.Equal(disk, tc.base) {
+		return &merged, nil, err
+	}
+	base, err := parseTunnels(tc.base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if disk, err = Upgrade(tunnelSchema, p, disk); err != nil {
+		return nil, nil, err
+	}
+	theirs, err := parseTunnels(disk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	var conflicts []string
+	merged.Tunnels, conflicts = merge(base.Tunnels, tunnels, theirs.Tunnels,
+		func(t model.Tunnel) string { return t.ID },
+		func(t model.Tunnel) string { return t.Name })
+	return &merged, conflicts, nil
 }
 
-// AddTunnel appends a tunnel and saves.
+// AddTunnel appends a tunnel, giving it an ID if it has none, and saves.
 func (tc *TunnelConfig) AddTunnel(t model.Tunnel) error {
-	tc.Tunnels = append(tc.Tunnels, t)
-	return tc.Save()
+	if t.ID == "" {
+		t.ID = model.NewID()
+	}
+	return tc.save(append(slices.Clip(tc.Tunnels), t))
 }
 
-// UpdateTunnel replaces the tunnel at index i and saves.
-func (tc *TunnelConfig) UpdateTunnel(i int, t model.Tunnel) error {
-	if i < 0 || i >= len(tc.Tunnels) {
-		return nil
+// UpdateTunnel replaces the tunnel with the given ID and saves.
+func (tc *TunnelConfig) UpdateTunnel(id string, t model.Tunnel) error {
+	for i := range tc.Tunnels {
+		if tc.Tunnels[i].ID == id {
+			t.ID = id
+			tunnels := slices.Clone(tc.Tunnels)
+			tunnels[i] = t
+			return tc.save(tunnels)
+		}
 	}
-	tc.Tunnels[i] = t
-	return tc.Save()
+	return nil
 }
 
-// DeleteTunnel removes the tunnel at index i and saves.
-func (tc *TunnelConfig) DeleteTunnel(i int) error {
-	if i < 0 || i >= len(tc.Tunnels) {
-		return nil
+// DeleteTunnel removes the tunnel with the given ID and saves.
+func (tc *TunnelConfig) DeleteTunnel(id string) error {
+	for i := range tc.Tunnels {
+		if tc.Tunnels[i].ID == id {
+			return tc.save(slices.Delete(slices.Clone(tc.Tunnels), i, i+1))
+		}
 	}
-	tc.Tunnels = append(tc.Tunnels[:i], tc.Tunnels[i+1:]...)
-	return tc.Save()
+	return nil
 }
 
 // FindTunnelByName returns the index and tunnel with the given name, or -1 if not found.
@@ -101,3 +230,85 @@ func (tc *TunnelConfig) FindTunnelByName(name string) (int, *model.Tunnel) {
 	}
 	return -1, nil
 }
+
+// ResolveTunnel returns t with its connection settings inherited from the
+// saved server it references. Settings on the tunnel override the server's.
+func (c *Config) ResolveTunnel(t model.Tunnel) (model.Tunnel, error) {
+	if t.Server == "" {
+		return t, nil
+	}
+	_, s := c.FindByName(t.Server)
+	if s == nil {
+		return t, fmt.Errorf("tunnel %q: server %q not found", t.Name, t.Server)
+	}
+	if t.SSHHost == "" {
+		t.SSHHost = s.Host
+	}
+	if t.SSHUser == "" {
+		t.SSHUser = s.User
+	}
+	if t.SSHPort == 0 {
+		t.SSHPort = s.Port
+	}
+	if t.SSHKey == "" {
+		t.SSHKey = s.Key
+	}
+	if len(t.SSHJump) == 0 {
+		t.SSHJump = s.Jump
+	}
+	if t.SSHProxyCommand == "" {
+		t.SSHProxyCommand = s.ProxyCommand
+	}
+	if t.Hooks.Pre == "" {
+		t.Hooks.Pre = s.Hooks.Pre
+	}
+	if t.Hooks.Post == "" {
+		t.Hooks.Post = s.Hooks.Post
+	}
+	if len(s.Options) > 0 {
+		opts := make(map[string]string, len(s.Options)+len(t.SSHOptions))
+		for k, v := range s.Options {
+			opts[k] = v
+		}
+		for k, v := range t.SSHOptions {
+			opts[k] = v
+		}
+		t.SSHOptions = opts
+	}
+	return t, nil
+}
+
+// Dependents returns the names of tunnels that use the named server,
+// either as their server or as a jump host.
+func (tc *TunnelConfig) Dependents(server string) []string {
+	var names []string
+	for _, t := range tc.Tunnels {
+		if t.Server == server || contains(t.SSHJump, server) {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// RenameServer points tunnels that use server oldName at newName and saves
+// if anything changed.
+func (tc *TunnelConfig) RenameServer(oldName, newName string) error {
+	changed := false
+	tunnels := slices.Clone(tc.Tunnels)
+	for i := range tunnels {
+		t := &tunnels[i]
+		if t.Server == oldName {
+			t.Server = newName
+			changed = true
+		}
+		if contains(t.SSHJump, oldName) {
+			t.SSHJump = slices.Clone(t.SSHJump)
+			replaceAll(t.SSHJump, oldName, newName)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return tc.save(tunnels)
+}
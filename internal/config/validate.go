@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"sshh/internal/model"
+	"sshh/internal/sshconfig"
+)
+
+// FieldError is a problem with one field of a server or tunnel.
+type FieldError struct {
+	Field   string // yaml key of the field, e.g. "host" or "remote_port"
+	Forward int    // index into the tunnel's forwards for forward fields, otherwise -1
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Forward >= 0 {
+		return fmt.Sprintf("forward %d: %s", e.Forward+1, e.Message)
+	}
+	return e.Message
+}
+
+// ValidateServer checks s, as it would be saved alongside servers. s itself
+// may be among them; entries with its ID are skipped.
+func ValidateServer(s model.Server, servers []model.Server) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Forward: -1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case s.Name == "":
+		add("name", "name is required")
+	case nameTaken(s.Name, s.ID, servers, func(o model.Server) (string, string) { return o.Name, o.ID }):
+		add("name", "another server is called %q", s.Name)
+	}
+	if s.Host == "" {
+		add("host", "host is required")
+	}
+	if !validPort(s.Port) {
+		add("port", "port must be between 1 and 65535")
+	}
+	if err := CheckKey(s.Key); err != nil {
+		add("key", "%v", err)
+	}
+	if err := sshconfig.CheckRequestTTY(s.RequestTTY); err != nil {
+		add("request_tty", "%v", err)
+	}
+	if _, err := sshconfig.ValidateOptions(s.Options); err != nil {
+		add("options", "%v", err)
+	}
+	return errs
+}
+
+// ValidateTunnel checks t, as it would be saved alongside tunnels, with its
+// server looked up in servers. t itself may be among tunnels; entries with
+// its ID are skipped.
+func ValidateTunnel(t model.Tunnel, tunnels []model.Tunnel, servers []model.Server) []FieldError {
+	var errs []FieldError
+	add := func(field string, fwd int, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Forward: fwd, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case t.Name == "":
+		add("name", -1, "name is required")
+	case nameTaken(t.Name, t.ID, tunnels, func(o model.Tunnel) (string, string) { return o.Name, o.ID }):
+		add("name", -1, "another tunnel is called %q", t.Name)
+	}
+	if t.Server != "" {
+		if !nameTaken(t.Server, "", servers, func(o model.Server) (string, string) { return o.Name, o.ID }) {
+			add("server", -1, "server %q not found", t.Server)
+		}
+	} else if t.SSHHost == "" {
+		add("ssh_host", -1, "SSH host is required unless a server is picked")
+	}
+	if t.SSHPort != 0 && !validPort(t.SSHPort) {
+		add("ssh_port", -1, "port must be between 1 and 65535")
+	}
+	if err := CheckKey(t.SSHKey); err != nil {
+		add("ssh_key", -1, "%v", err)
+	}
+
+	if len(t.Forwards) == 0 {
+		add("forwards", -1, "at least one forward is required")
+	}
+	for i, f := range t.Forwards {
+		switch f.Type {
+		case model.TunnelLocal, model.TunnelRemote, model.TunnelDynamic:
+		default:
+			add("type", i, "type must be local, remote or dynamic")
+		}
+		if f.LocalPort == 0 {
+			add("local_port", i, "local port is required")
+		} else if !validPort(f.LocalPort) {
+			add("local_port", i, "local port must be between 1 and 65535")
+		} else if other := portUser(t, i, tunnels); other != "" {
+			add("local_port", i, "local port %d is also used by %s", f.LocalPort, other)
+		}
+		if f.Type == model.TunnelLocal && f.RemoteHost == "" {
+			add("remote_host", i, "remote host is required")
+		}
+		if f.Type == model.TunnelLocal || f.Type == model.TunnelRemote {
+			if f.RemotePort == 0 {
+				add("remote_port", i, "remote port is required")
+			} else if !validPort(f.RemotePort) {
+				add("remote_port", i, "remote port must be between 1 and 65535")
+			}
+		}
+	}
+	return errs
+}
+
+// nameTaken reports whether an entry other than the one with ID id is
+// called name. With no id, any entry counts.
+func nameTaken[T any](name, id string, list []T, key func(T) (name, id string)) bool {
+	for _, v := range list {
+		if n, i := key(v); n == name && (id == "" || i != id) {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(p int) bool {
+	return p >= 1 && p <= 65535
+}
+
+// CheckKey returns an error if the identity file at path doesn't exist. An
+// unset key is not an error.
+func CheckKey(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(sshconfig.ExpandTilde(path)); err != nil {
+		return fmt.Errorf("key file %s not found", path)
+	}
+	return nil
+}
+
+// binds reports whether a forward listens on a port on this machine.
+func binds(f model.Forward) bool {
+	return f.Type == model.TunnelLocal || f.Type == model.TunnelDynamic
+}
+
+// portUser describes another forward that listens on the same local port as
+// t.Forwards[i], in t or another tunnel, or returns "" if there is none.
+func portUser(t model.Tunnel, i int, tunnels []model.Tunnel) string {
+	f := t.Forwards[i]
+	if !binds(f) {
+		return ""
+	}
+	for j, o := range t.Forwards[:i] {
+		if binds(o) && o.LocalPort == f.LocalPort {
+			return fmt.Sprintf("forward %d", j+1)
+		}
+	}
+	for _, other := range tunnels {
+		if other.ID == t.ID && t.ID != "" {
+			continue
+		}
+		for _, o := range other.Forwards {
+			if binds(o) && o.LocalPort == f.LocalPort {
+				return fmt.Sprintf("tunnel %q", other.Name)
+			}
+		}
+	}
+	return ""
+}
+
+// CheckKeyMode returns an error if the identity file at path can be read by
+// anyone but its owner, which makes ssh refuse it. A missing file is not an
+// error here; ValidateServer reports it.
+func CheckKeyMode(path string) error {
+	info, err := os.Stat(sshconfig.ExpandTilde(path))
+	if err != nil {
+		return nil
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("key file %s has mode %04o; ssh refuses keys others can read (chmod 600 %s)", path, perm, path)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"sshh/internal/config"
+)
+
+// ErrNotRunning is returned when no daemon is listening on the control socket.
+var ErrNotRunning = errors.New("sshh daemon is not running")
+
+// call sends one request to the daemon and returns its response.
+func call(req Request) (Response, error) {
+	p, err := SocketPath()
+	if err != nil {
+		return Response{}, err
+	}
+	conn, err := net.DialTimeout("unix", p, time.Second)
+	if err != nil {
+		return Response{}, ErrNotRunning
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Running reports whether a daemon is answering on the control socket.
+func Running() bool {
+	_, err := call(Request{Op: OpList})
+	return err == nil
+}
+
+// EnsureRunning starts a detached daemon if none is running and waits for
+// its socket to come up.
+func EnsureRunning() error {
+	if Running() {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logf, err := config.OpenLog("daemon.log")
+	if err != nil {
+		return err
+	}
+	defer logf.Close()
+
+	cmd := exec.Command(exe, "daemon")
+	cmd.Stdout = logf
+	cmd.Stderr = logf
+	// New session so the daemon outlives the terminal that spawned it.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting daemon: %w", err)
+	}
+	_ = cmd.Process.Release()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if Running() {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon did not start; see %s", logf.Name())
+}
+
+// Start asks the daemon to bring up the named tunnel, spawning it if needed.
+// client names the caller in the event log. The tunnel's pre-connect hooks
+// are the caller's to run first.
+func Start(name, client string) error {
+	if err := EnsureRunning(); err != nil {
+		return err
+	}
+	_, err := call(Request{Op: OpStart, Name: name, Client: client})
+	return err
+}
+
+// Stop asks the daemon to take down the named tunnel.
+func Stop(name string) error {
+	_, err := call(Request{Op: OpStop, Name: name})
+	return err
+}
+
+// List returns every tunnel the daemon knows about.
+func List() ([]Status, error) {
+	resp, err := call(Request{Op: OpList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tunnels, nil
+}
+
+// Get returns the status of the named tunnel.
+func Get(name string) (Status, error) {
+	resp, err := call(Request{Op: OpStatus, Name: name})
+	if err != nil {
+		return Status{}, err
+	}
+	if len(resp.Tunnels) == 0 {
+		return Status{}, fmt.Errorf("tunnel %q is not running", name)
+	}
+	return resp.Tunnels[0], nil
+}
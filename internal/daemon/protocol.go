@@ -0,0 +1,65 @@
+// Package daemon runs tunnels in the background and exposes a Unix-socket
+// control API so the TUI and CLI can start, stop and inspect them.
+package daemon
+
+import (
+	"path/filepath"
+	"time"
+
+	"sshh/internal/config"
+)
+
+// Op is a control request operation.
+type Op string
+
+const (
+	OpStart  Op = "start"
+	OpStop   Op = "stop"
+	OpList   Op = "list"
+	OpStatus Op = "status"
+)
+
+// State describes where a supervised tunnel is in its lifecycle.
+type State string
+
+const (
+	StateRunning      State = "running"
+	StateReconnecting State = "reconnecting"
+	StateExited       State = "exited"
+	StateFailed       State = "failed"
+)
+
+// Request is a single control message sent by a client.
+// Requests and responses are newline-delimited JSON, one pair per connection.
+type Request struct {
+	Op     Op     `json:"op"`
+	Name   string `json:"name,omitempty"`
+	Client string `json:"client,omitempty"` // who asked for a start, for the event log
+}
+
+// Response is the daemon's reply to a Request.
+type Response struct {
+	OK      bool     `json:"ok"`
+	Error   string   `json:"error,omitempty"`
+	Tunnels []Status `json:"tunnels,omitempty"`
+}
+
+// Status is a snapshot of one tunnel known to the daemon.
+type Status struct {
+	Name  string    `json:"name"`
+	State State     `json:"state"`
+	PID   int       `json:"pid,omitempty"`
+	Since time.Time `json:"since"`
+	Error string    `json:"error,omitempty"`
+
+	Restarts int `json:"restarts,omitempty"` // reconnect attempts since the last stable connection
+}
+
+// SocketPath returns the control socket path (~/.sshh/daemon.sock).
+func SocketPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
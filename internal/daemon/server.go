@@ -0,0 +1,297 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/history"
+	"sshh/internal/sshexec"
+)
+
+// Daemon supervises background tunnels and serves the control socket.
+type Daemon struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	tunnels map[string]*managed
+	out     io.Writer // receives ssh output
+	log     *log.Logger
+}
+
+// managed is a supervised tunnel owned by the daemon.
+type managed struct {
+	status  Status
+	cancel  context.CancelFunc
+	stopped bool // stop was requested; exit is expected
+}
+
+// New creates a daemon that writes its own log lines and ssh output to out.
+func New(out io.Writer) *Daemon {
+	return &Daemon{
+		tunnels: make(map[string]*managed),
+		out:     out,
+		log:     log.New(out, "sshh daemon: ", log.LstdFlags),
+	}
+}
+
+// Serve listens on the control socket until ctx is cancelled,
+// then stops every tunnel it started.
+func (d *Daemon) Serve(ctx context.Context) error {
+	if Running() {
+		return errors.New("daemon already running")
+	}
+
+	p, err := SocketPath()
+	if err != nil {
+		return err
+	}
+	// A socket left behind by a crashed daemon would make Listen fail.
+	_ = os.Remove(p)
+
+	ln, err := net.Listen("unix", p)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(p)
+	if err := os.Chmod(p, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	d.log.Printf("listening on %s", p)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				d.stopAll()
+				return nil
+			}
+			return err
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	_ = json.NewEncoder(conn).Encode(d.dispatch(req))
+}
+
+func (d *Daemon) dispatch(req Request) Response {
+	var err error
+	switch req.Op {
+	case OpStart:
+		err = d.start(req.Name, req.Client)
+	case OpStop:
+		err = d.stop(req.Name)
+	case OpList:
+		return Response{OK: true, Tunnels: d.list()}
+	case OpStatus:
+		st, ok := d.status(req.Name)
+		if !ok {
+			return Response{Error: fmt.Sprintf("tunnel %q is not running", req.Name)}
+		}
+		return Response{OK: true, Tunnels: []Status{st}}
+	default:
+		err = fmt.Errorf("unknown op %q", req.Op)
+	}
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return Response{OK: true}
+}
+
+// start launches the named tunnel. Definitions are read from disk on every
+// start so edits made in the TUI take effect without restarting the daemon.
+// The client runs the pre-connect hooks on its terminal before asking, since
+// they may prompt; post-connect hooks run here, with output in the daemon
+// log. The session is recorded in the event log under client.
+func (d *Daemon) start(name, client string) error {
+	if d.running(name) {
+		return fmt.Errorf("tunnel %q is already running", name)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tc, err := config.LoadTunnels()
+	if err != nil {
+		return err
+	}
+	_, t := tc.FindTunnelByName(name)
+	if t == nil {
+		return fmt.Errorf("tunnel %q not found", name)
+	}
+	resolved, err := cfg.ResolveTunnel(*t)
+	if err != nil {
+		return err
+	}
+
+	hooks := sshexec.Hooks{cfg.Settings.Hooks, resolved.Hooks}
+	env := sshexec.TunnelEnv(resolved)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if m, ok := d.tunnels[name]; ok && m.active() {
+		return fmt.Errorf("tunnel %q is already running", name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &managed{
+		status: Status{Name: name, State: StateRunning, Since: time.Now()},
+		cancel: cancel,
+	}
+	sup := &sshexec.Supervisor{
+		Tunnel:  resolved,
+		Servers: cfg.Servers,
+		Stdout:  d.out,
+		Stderr:  d.out,
+		Log:     d.log,
+		OnStart: func(pid int) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			m.status.State = StateRunning
+			m.status.PID = pid
+			m.status.Since = time.Now()
+			d.log.Printf("started tunnel %q (pid %d)", name, pid)
+		},
+		OnReady: func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			m.status.Error = ""
+		},
+		OnRetry: func(err error, attempt int, delay time.Duration) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			m.status.State = StateReconnecting
+			m.status.PID = 0
+			m.status.Restarts = attempt
+			if err != nil {
+				m.status.Error = err.Error()
+			}
+		},
+	}
+	d.tunnels[name] = m
+
+	ev, err := history.Begin(history.KindTunnel, t.ID, name, client)
+	if err != nil {
+		d.log.Printf("tunnel %q: recording start: %v", name, err)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		err := sup.Run(ctx)
+		code := sshexec.ExitCode(err)
+		hooks.RunPost(env, code, nil, d.out, d.out)
+		if err := history.Finish(ev, code); err != nil {
+			d.log.Printf("tunnel %q: recording end: %v", name, err)
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if m.stopped {
+			d.log.Printf("stopped tunnel %q", name)
+			if d.tunnels[name] == m {
+				delete(d.tunnels, name)
+			}
+			return
+		}
+		m.status.PID = 0
+		m.status.Since = time.Now()
+		if err != nil {
+			m.status.State = StateFailed
+			m.status.Error = err.Error()
+			d.log.Printf("tunnel %q failed: %v", name, err)
+		} else {
+			m.status.State = StateExited
+			d.log.Printf("tunnel %q exited", name)
+		}
+	}()
+	return nil
+}
+
+// running reports whether the named tunnel is active.
+func (d *Daemon) running(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m, ok := d.tunnels[name]
+	return ok && m.active()
+}
+
+// stop terminates the named tunnel, or forgets it if it has already exited.
+func (d *Daemon) stop(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, ok := d.tunnels[name]
+	if !ok {
+		return fmt.Errorf("tunnel %q is not running", name)
+	}
+	if !m.active() {
+		delete(d.tunnels, name)
+		return nil
+	}
+	m.stopped = true
+	m.cancel()
+	return nil
+}
+
+// stopAll stops every tunnel and waits for their ssh processes to exit.
+func (d *Daemon) stopAll() {
+	d.mu.Lock()
+	for _, m := range d.tunnels {
+		if m.active() {
+			m.stopped = true
+			m.cancel()
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// active reports whether the tunnel is still being supervised.
+func (m *managed) active() bool {
+	return m.status.State == StateRunning || m.status.State == StateReconnecting
+}
+
+func (d *Daemon) list() []Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Status, 0, len(d.tunnels))
+	for _, m := range d.tunnels {
+		out = append(out, m.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (d *Daemon) status(name string) (Status, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m, ok := d.tunnels[name]
+	if !ok {
+		return Status{}, false
+	}
+	return m.status, true
+}
@@ -0,0 +1,216 @@
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sshh/internal/config"
+)
+
+// Kinds of session recorded in the event log.
+const (
+	KindServer = "server"
+	KindTunnel = "tunnel"
+)
+
+// Clients that start sessions.
+const (
+	ClientCLI = "cli"
+	ClientTUI = "tui"
+)
+
+// Event is one session in the event log. A session is appended once when it
+// starts and again, with End and Exit set, when it ends; readers keep the
+// last line for each ID. A session handed over to ssh with exec never gets
+// an end line.
+type Event struct {
+	ID     string    `json:"id"`
+	Kind   string    `json:"kind"`
+	Target string    `json:"target,omitempty"` // ID of the server or tunnel
+	Name   string    `json:"name"`             // its name at the time
+	Client string    `json:"client,omitempty"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end,omitzero"`
+	Exit   *int      `json:"exit,omitempty"`
+}
+
+// Ended reports whether the end of the session was recorded.
+func (e Event) Ended() bool {
+	return !e.End.IsZero()
+}
+
+// Duration returns how long the session lasted, or zero if its end is unknown.
+func (e Event) Duration() time.Duration {
+	if !e.Ended() {
+		return 0
+	}
+	return e.End.Sub(e.Start)
+}
+
+// eventsPath returns the full path to events.jsonl.
+func eventsPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "events.jsonl"), nil
+}
+
+// Begin appends the start of a session to the event log. target is the ID of
+// the server or tunnel and name its current name.
+func Begin(kind, target, name, client string) (Event, error) {
+	id, err := newID()
+	if err != nil {
+		return Event{}, err
+	}
+	if err := seedEvents(); err != nil {
+		return Event{}, err
+	}
+	e := Event{ID: id, Kind: kind, Target: target, Name: name, Client: client, Start: time.Now()}
+	return e, appendEvents(e)
+}
+
+// Finish appends the end of a session started with Begin. A zero Event, as
+// returned when Begin failed, is ignored.
+func Finish(e Event, exitCode int) error {
+	if e.ID == "" {
+		return nil
+	}
+	e.End = time.Now()
+	e.Exit = &exitCode
+	return appendEvents(e)
+}
+
+// Events reads the event log, oldest session first. Lines that don't parse
+// are skipped. A missing log has no events.
+func Events() ([]Event, error) {
+	if err := seedEvents(); err != nil {
+		return nil, err
+	}
+	p, err := eventsPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	pos := make(map[string]int)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		if json.Unmarshal(sc.Bytes(), &e) != nil || e.ID == "" {
+			continue
+		}
+		if i, ok := pos[e.ID]; ok {
+			events[i] = e
+			continue
+		}
+		pos[e.ID] = len(events)
+		events = append(events, e)
+	}
+	return events, sc.Err()
+}
+
+// LastSessions returns the most recent session of the given kind for each
+// server or tunnel ID in the log. Sessions logged before IDs existed are
+// left out.
+func LastSessions(kind string) (map[string]Event, error) {
+	events, err := Events()
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]Event)
+	for _, e := range events {
+		if e.Kind == kind && e.Target != "" && !e.Start.Before(last[e.Target].Start) {
+			last[e.Target] = e
+		}
+	}
+	return last, nil
+}
+
+// appendEvents writes events as JSON lines to the end of the log. Each line
+// goes out in a single write, so concurrent writers don't interleave.
+func appendEvents(events ...Event) error {
+	p, err := eventsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// seedEvents starts the event log from the connection times in history.json,
+// which is all older versions recorded. It does nothing once the log exists.
+func seedEvents() error {
+	p, err := eventsPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(p); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+	h, err := Load()
+	if err != nil || len(h.Servers) == 0 {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var events []Event
+	for target, r := range h.Servers {
+		s := cfg.FindByID(target)
+		if s == nil {
+			continue
+		}
+		for _, t := range r.Times {
+			id, err := newID()
+			if err != nil {
+				return err
+			}
+			events = append(events, Event{ID: id, Kind: KindServer, Target: target, Name: s.Name, Start: t})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return appendEvents(events...)
+}
+
+// newID returns a random session ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -2,18 +2,69 @@ package history
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
 	"sshh/internal/config"
-	"sshh/internal/model"
 )
 
-// History tracks when each server was last connected to.
+// maxTimes caps how many connection times are kept per server; older ones
+// barely move the frecency score.
+const maxTimes = 100
+
+// History tracks when each server was connected to.
 type History struct {
-	Entries map[string]time.Time `json:"entries"`
+	Version int                `json:"version"`
+	Servers map[string]*Record `json:"servers"` // by server ID
+
+	pending map[string][]time.Time // recorded since the last save
+}
+
+// Record is the connection history of one server.
+type Record struct {
+	Times []time.Time `json:"times"` // most recent connections, oldest first
+	Count int         `json:"count"` // all connections, including ones dropped from Times
+}
+
+// schema is the version history of history.json.
+var schema = config.Schema{
+	File: "history.json",
+	Steps: []config.Migration{
+		// 2: connection times per server instead of only the last one and,
+		// in later version 1 builds, a count. The last time becomes the
+		// single entry in times.
+		func(doc config.Document) error {
+			entries, counts := doc.Map("entries"), doc.Map("counts")
+			servers := make(map[string]any, len(entries))
+			for name, t := range entries {
+				count, _ := counts[name].(float64)
+				servers[name] = map[string]any{"times": []any{t}, "count": max(count, 1)}
+			}
+			delete(doc, "entries")
+			delete(doc, "counts")
+			doc["servers"] = servers
+			return nil
+		},
+		// 3: records are keyed by server ID instead of name. Records for
+		// servers that no longer exist are dropped.
+		func(doc config.Document) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			byID := make(map[string]any)
+			for name, r := range doc.Map("servers") {
+				if _, s := cfg.FindByName(name); s != nil {
+					byID[s.ID] = r
+				}
+			}
+			doc["servers"] = byID
+			return nil
+		},
+	},
 }
 
 // filePath returns the full path to history.json.
@@ -26,6 +77,7 @@ func filePath() (string, error) {
 }
 
 // Load reads history from disk. Returns empty history if the file doesn't exist.
+// Older formats are upgraded on load.
 func Load() (*History, error) {
 	p, err := filePath()
 	if err != nil {
@@ -35,65 +87,107 @@ func Load() (*History, error) {
 	data, err := os.ReadFile(p)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &History{Entries: make(map[string]time.Time)}, nil
+			return &History{Version: schema.Current(), Servers: make(map[string]*Record)}, nil
 		}
 		return nil, err
 	}
+	return parse(p, data)
+}
 
+// parse decodes history.json, read from p, upgrading it first if needed.
+func parse(p string, data []byte) (*History, error) {
+	data, err := config.Upgrade(schema, p, data)
+	if err != nil {
+		return nil, err
+	}
 	var h History
 	if err := json.Unmarshal(data, &h); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", p, err)
 	}
-	if h.Entries == nil {
-		h.Entries = make(map[string]time.Time)
+	if h.Servers == nil {
+		h.Servers = make(map[string]*Record)
 	}
 	return &h, nil
 }
 
-// Save writes history to disk.
+// Save writes history to disk. Connections recorded since the last save are
+// added to what is on disk, so other sshh processes' records are kept, and
+// h is updated to the result.
 func (h *History) Save() error {
-	dir, err := config.Dir()
+	p, err := filePath()
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(dir, 0700); err != nil {
+
+	unlock, err := config.Lock()
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	p, err := filePath()
-	if err != nil {
+	disk := &History{Servers: make(map[string]*Record)}
+	if data, err := os.ReadFile(p); err == nil {
+		if disk, err = parse(p, data); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
 		return err
 	}
+	for id, times := range h.pending {
+		disk.add(id, times...)
+	}
 
-	data, err := json.MarshalIndent(h, "", "  ")
+	disk.Version = schema.Current()
+	data, err := json.MarshalIndent(disk, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0600)
+	if err := config.WriteFileAtomic(p, data, 0600); err != nil {
+		return err
+	}
+	h.Version, h.Servers, h.pending = disk.Version, disk.Servers, nil
+	return nil
 }
 
-// Record marks a server as just used and saves.
-func (h *History) Record(serverName string) error {
-	h.Entries[serverName] = time.Now()
+// Record marks the server with the given ID as just used and saves.
+func (h *History) Record(serverID string) error {
+	now := time.Now()
+	h.add(serverID, now)
+	if h.pending == nil {
+		h.pending = make(map[string][]time.Time)
+	}
+	h.pending[serverID] = append(h.pending[serverID], now)
 	return h.Save()
 }
 
-// SortByRecent returns a copy of servers sorted by most recently used first.
-// Servers with no history are placed at the end in their original order.
-func (h *History) SortByRecent(servers []model.Server) []model.Server {
-	sorted := make([]model.Server, len(servers))
-	copy(sorted, servers)
-
-	sort.SliceStable(sorted, func(i, j int) bool {
-		ti, oki := h.Entries[sorted[i].Name]
-		tj, okj := h.Entries[sorted[j].Name]
-		if oki && okj {
-			return ti.After(tj)
-		}
-		if oki {
-			return true
-		}
-		return false
-	})
-	return sorted
+// add appends connection times to a server's record.
+func (h *History) add(serverID string, times ...time.Time) {
+	r := h.Servers[serverID]
+	if r == nil {
+		r = &Record{}
+		h.Servers[serverID] = r
+	}
+	r.Times = append(r.Times, times...)
+	sort.Slice(r.Times, func(i, j int) bool { return r.Times[i].Before(r.Times[j]) })
+	if len(r.Times) > maxTimes {
+		r.Times = r.Times[len(r.Times)-maxTimes:]
+	}
+	r.Count += len(times)
+}
+
+// Last returns when a server was last connected to, and whether it ever was.
+func (h *History) Last(serverID string) (time.Time, bool) {
+	r := h.Servers[serverID]
+	if r == nil || len(r.Times) == 0 {
+		return time.Time{}, false
+	}
+	return r.Times[len(r.Times)-1], true
+}
+
+// Count returns how many connections to a server have been recorded.
+func (h *History) Count(serverID string) int {
+	if r := h.Servers[serverID]; r != nil {
+		return r.Count
+	}
+	return 0
 }
@@ -0,0 +1,91 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sshh/internal/model"
+	"sshh/internal/testutil"
+)
+
+// v1Config is the config.yaml the v1 history was written against: servers
+// without IDs, which get their legacy ones on load.
+const v1Config = `servers:
+  - name: web
+    host: 10.0.0.1
+  - name: db
+    host: 10.0.0.2
+`
+
+func TestUpgradeV1(t *testing.T) {
+	dir := testutil.InstallFixture(t, filepath.Join("testdata", "v1"))
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(v1Config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != schema.Current() {
+		t.Errorf("version = %d, want %d", h.Version, schema.Current())
+	}
+
+	web, db := model.LegacyID("web", 0), model.LegacyID("db", 0)
+	tests := []struct {
+		id    string
+		last  string
+		count int
+	}{
+		{web, "2026-01-02T03:04:05Z", 7}, // count from the later v1 counts map
+		{db, "2026-01-01T00:00:00Z", 1},  // no count: the one known connection
+	}
+	for _, tt := range tests {
+		r := h.Servers[tt.id]
+		if r == nil {
+			t.Errorf("no record for %s", tt.id)
+			continue
+		}
+		want, _ := time.Parse(time.RFC3339, tt.last)
+		if len(r.Times) != 1 || !r.Times[0].Equal(want) {
+			t.Errorf("%s: times = %v, want [%v]", tt.id, r.Times, want)
+		}
+		if r.Count != tt.count {
+			t.Errorf("%s: count = %d, want %d", tt.id, r.Count, tt.count)
+		}
+	}
+	if len(h.Servers) != 2 {
+		t.Errorf("servers = %v; the record for a deleted server should be dropped", h.Servers)
+	}
+
+	orig, err := os.ReadFile(filepath.Join("testdata", "v1", "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(filepath.Join(dir, "history.json.v1.bak"))
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if string(backup) != string(orig) {
+		t.Errorf("backup = %q, want the original", backup)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version": 3`) {
+		t.Errorf("history.json was not rewritten:\n%s", data)
+	}
+
+	// The rewritten file loads to the same records.
+	again, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Count(web) != 7 || again.Count(db) != 1 {
+		t.Errorf("after rewrite, counts = %d, %d, want 7, 1", again.Count(web), again.Count(db))
+	}
+}
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"sshh/internal/model"
+)
+
+// Strategy is a way of ordering the server list.
+type Strategy string
+
+const (
+	SortFrecency     Strategy = "frecency"     // often and recently used first
+	SortRecent       Strategy = "mru"          // most recently used first
+	SortAlphabetical Strategy = "alphabetical" // by name
+	SortConfig       Strategy = "config"       // as written in config.yaml
+)
+
+// Strategies lists the strategies in the order the TUI cycles through them.
+var Strategies = []Strategy{SortFrecency, SortRecent, SortAlphabetical, SortConfig}
+
+// DefaultStrategy is used when settings don't name one.
+const DefaultStrategy = SortFrecency
+
+// halfLife is how long it takes a connection to count for half as much in
+// the frecency score.
+const halfLife = 7 * 24 * time.Hour
+
+// ParseStrategy checks a strategy name from settings; empty means the default.
+func ParseStrategy(name string) (Strategy, error) {
+	if name == "" {
+		return DefaultStrategy, nil
+	}
+	for _, s := range Strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sort %q (want frecency, mru, alphabetical or config)", name)
+}
+
+// Next returns the strategy after s in Strategies, wrapping around.
+func (s Strategy) Next() Strategy {
+	for i, v := range Strategies {
+		if v == s {
+			return Strategies[(i+1)%len(Strategies)]
+		}
+	}
+	return DefaultStrategy
+}
+
+// Label describes the strategy for the TUI.
+func (s Strategy) Label() string {
+	switch s {
+	case SortRecent:
+		return "most recently used"
+	case SortAlphabetical:
+		return "name"
+	case SortConfig:
+		return "config order"
+	}
+	return "frecency"
+}
+
+// Sort returns a copy of servers in the order given by strategy. For the
+// history-based strategies, servers with no history keep their config order
+// after the rest.
+func (h *History) Sort(servers []model.Server, strategy Strategy) []model.Server {
+	sorted := make([]model.Server, len(servers))
+	copy(sorted, servers)
+
+	switch strategy {
+	case SortConfig:
+	case SortAlphabetical:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+		})
+	case SortRecent:
+		sortByScore(sorted, func(id string) float64 {
+			if t, ok := h.Last(id); ok {
+				return float64(t.UnixNano())
+			}
+			return math.Inf(-1)
+		})
+	default:
+		now := time.Now()
+		sortByScore(sorted, func(id string) float64 {
+			return h.Frecency(id, now)
+		})
+	}
+	return sorted
+}
+
+// Frecency scores a server by how often and how recently it was used: each
+// recorded connection counts 1, halving every halfLife since it was made.
+// Servers with no history score 0.
+func (h *History) Frecency(serverID string, now time.Time) float64 {
+	r := h.Servers[serverID]
+	if r == nil {
+		return 0
+	}
+	var score float64
+	for _, t := range r.Times {
+		age := max(now.Sub(t), 0)
+		score += math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return score
+}
+
+// sortByScore orders servers by descending score, keeping ties in order.
+func sortByScore(servers []model.Server, score func(id string) float64) {
+	scores := make(map[string]float64, len(servers))
+	for _, s := range servers {
+		scores[s.ID] = score(s.ID)
+	}
+	sort.SliceStable(servers, func(i, j int) bool {
+		return scores[servers[i].ID] > scores[servers[j].ID]
+	})
+}
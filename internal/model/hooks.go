@@ -0,0 +1,8 @@
+package model
+
+// Hooks are shell commands run around a connection. Pre runs before ssh
+// starts and aborts the connection if it fails; Post runs after it ends.
+type Hooks struct {
+	Pre  string `yaml:"pre,omitempty" json:"pre,omitempty"`
+	Post string `yaml:"post,omitempty" json:"post,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// NewID returns a random ID for a server or tunnel.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // never fails
+	return hex.EncodeToString(b)
+}
+
+// LegacyID returns the ID given to the nth entry named name that was saved
+// without one, by an older sshh or by hand. It is derived from the name so
+// every process reading the same file agrees on it until it is saved.
+func LegacyID(name string, n int) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + strconv.Itoa(n)))
+	return hex.EncodeToString(sum[:8])
+}
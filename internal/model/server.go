@@ -1,11 +1,39 @@
 package model
 
+import "strings"
+
 // Server represents an SSH server configuration.
 type Server struct {
-	Name string   `yaml:"name"`
-	Host string   `yaml:"host"`
-	User string   `yaml:"user"`
-	Port int      `yaml:"port"`
-	Key  string   `yaml:"key,omitempty"`
-	Tags []string `yaml:"tags,omitempty"`
+	ID   string   `yaml:"id" json:"id"` // stable across renames; see NewID
+	Name string   `yaml:"name" json:"name"`
+	Host string   `yaml:"host" json:"host"`
+	User string   `yaml:"user" json:"user"`
+	Port int      `yaml:"port" json:"port"`
+	Key  string   `yaml:"key,omitempty" json:"key,omitempty"`
+	Jump []string `yaml:"jump,omitempty" json:"jump,omitempty"` // jump hosts, in hop order: saved server names or [user@]host[:port]
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	Group string `yaml:"group,omitempty" json:"group,omitempty"` // slash-separated folder path, e.g. prod/eu/db
+
+	ProxyCommand string            `yaml:"proxy_command,omitempty" json:"proxy_command,omitempty"` // ignored when Jump is set
+	Options      map[string]string `yaml:"options,omitempty" json:"options,omitempty"`             // extra ssh -o options, keyed by ssh_config name
+
+	RemoteCommand string `yaml:"remote_command,omitempty" json:"remote_command,omitempty"` // run on connect instead of a login shell
+	RequestTTY    string `yaml:"request_tty,omitempty" json:"request_tty,omitempty"`       // yes, no, force or auto
+
+	Hooks Hooks `yaml:"hooks,omitempty" json:"hooks,omitzero"`
+
+	Notes string `yaml:"notes,omitempty" json:"notes,omitempty"` // free text shown in the detail pane
+}
+
+// GroupPath returns the server's group split into folders, skipping empty
+// segments so "prod//eu/" reads as prod/eu.
+func (s Server) GroupPath() []string {
+	var path []string
+	for _, seg := range strings.Split(s.Group, "/") {
+		if seg = strings.TrimSpace(seg); seg != "" {
+			path = append(path, seg)
+		}
+	}
+	return path
 }
@@ -9,15 +9,42 @@ const (
 	TunnelDynamic TunnelType = "dynamic"
 )
 
-// Tunnel represents a saved SSH tunnel template.
-type Tunnel struct {
-	Name       string     `yaml:"name"`
-	SSHHost    string     `yaml:"ssh_host"`
-	SSHUser    string     `yaml:"ssh_user,omitempty"`
-	SSHPort    int        `yaml:"ssh_port,omitempty"`
-	SSHKey     string     `yaml:"ssh_key,omitempty"`
+// RestartPolicy controls whether a tunnel is restarted after ssh exits.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+// Forward is a single port forward carried by a tunnel.
+type Forward struct {
 	Type       TunnelType `yaml:"type"`
 	LocalPort  int        `yaml:"local_port"`
 	RemoteHost string     `yaml:"remote_host,omitempty"`
 	RemotePort int        `yaml:"remote_port,omitempty"`
 }
+
+// Tunnel represents a saved SSH tunnel template. All of its forwards share
+// one ssh connection. When Server names a saved server, the SSH fields are
+// inherited from it and any set here act as overrides.
+type Tunnel struct {
+	ID       string    `yaml:"id"` // stable across renames; see NewID
+	Name     string    `yaml:"name"`
+	Server   string    `yaml:"server,omitempty"`
+	SSHHost  string    `yaml:"ssh_host,omitempty"`
+	SSHUser  string    `yaml:"ssh_user,omitempty"`
+	SSHPort  int       `yaml:"ssh_port,omitempty"`
+	SSHKey   string    `yaml:"ssh_key,omitempty"`
+	SSHJump  []string  `yaml:"ssh_jump,omitempty"`
+	Forwards []Forward `yaml:"forwards"`
+
+	SSHProxyCommand string            `yaml:"ssh_proxy_command,omitempty"` // ignored when SSHJump is set
+	SSHOptions      map[string]string `yaml:"ssh_options,omitempty"`       // override the server's options per key
+
+	Restart    RestartPolicy `yaml:"restart,omitempty"`     // empty means never
+	MaxRetries int           `yaml:"max_retries,omitempty"` // 0 means unlimited
+
+	Hooks Hooks `yaml:"hooks,omitempty"` // each one set overrides the server's
+}
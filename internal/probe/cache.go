@@ -0,0 +1,50 @@
+package probe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"sshh/internal/config"
+)
+
+// cachePath returns the full path to status.json.
+func cachePath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "status.json"), nil
+}
+
+// LoadCache reads the last results from disk, so the list can show them
+// before the first check finishes. A missing or unreadable cache is empty.
+func LoadCache() map[string]Result {
+	results := make(map[string]Result)
+	p, err := cachePath()
+	if err != nil {
+		return results
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return results
+	}
+	_ = json.Unmarshal(data, &results)
+	return results
+}
+
+// SaveCache writes results to disk.
+func SaveCache(results map[string]Result) error {
+	p, err := cachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(p, data, 0600)
+}
@@ -0,0 +1,119 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"sshh/internal/model"
+)
+
+// Result is the outcome of checking one server.
+type Result struct {
+	Reachable bool          `json:"reachable"`        // TCP connect succeeded
+	Banner    string        `json:"banner,omitempty"` // SSH identification line, if one was read
+	RTT       time.Duration `json:"rtt,omitempty"`    // time to connect
+	Error     string        `json:"error,omitempty"`  // short reason when not up
+	Checked   time.Time     `json:"checked"`
+}
+
+// Up reports whether the server accepted a connection and spoke SSH.
+func (r Result) Up() bool {
+	return r.Reachable && r.Banner != ""
+}
+
+// Checkable reports whether a server can be probed directly. Servers behind
+// jump hosts or a proxy command usually can't be reached from here.
+func Checkable(s model.Server) bool {
+	return len(s.Jump) == 0 && s.ProxyCommand == "" && s.Host != ""
+}
+
+// Check dials the server's SSH port and reads its banner, giving up after
+// timeout.
+func Check(ctx context.Context, s model.Server, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	port := s.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(port))
+
+	res := Result{Checked: time.Now()}
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		res.Error = shortError(err)
+		return res
+	}
+	defer conn.Close()
+	res.Reachable = true
+	res.RTT = time.Since(start)
+
+	// The server sends its identification string first (RFC 4253 4.2).
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	line, err := bufio.NewReaderSize(conn, 256).ReadString('\n')
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "SSH-"):
+		res.Banner = line
+	case err != nil:
+		res.Error = "no banner"
+	default:
+		res.Error = "not ssh"
+	}
+	return res
+}
+
+// CheckAll probes the checkable servers, at most concurrency at a time, and
+// returns their results keyed by server ID.
+func CheckAll(ctx context.Context, servers []model.Server, concurrency int, timeout time.Duration) map[string]Result {
+	results := make(map[string]Result)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, s := range servers {
+		if !Checkable(s) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(s model.Server) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res := Check(ctx, s, timeout)
+			mu.Lock()
+			results[s.ID] = res
+			mu.Unlock()
+		}(s)
+	}
+	wg.Wait()
+	return results
+}
+
+// shortError turns a dial error into a word or two for the list.
+func shortError(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, syscall.ETIMEDOUT):
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "unreachable"
+	case errors.As(err, &dnsErr):
+		return "unknown host"
+	}
+	return "down"
+}
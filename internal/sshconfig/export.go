@@ -0,0 +1,115 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sshh/internal/model"
+)
+
+// Render writes servers and tunnels as ssh_config Host blocks. Tunnels must
+// already have their server reference resolved. Each tunnel gets a block of
+// its own, so that connecting to a server with ssh, scp, git or an editor
+// never tries to open a tunnel's forwards; one whose name is already used by
+// an earlier block is written as <name>-tunnel, suffixed again until unique.
+func Render(servers []model.Server, tunnels []model.Tunnel) string {
+	taken := make(map[string]bool)
+	var b strings.Builder
+	for _, s := range servers {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeBlock(&b, s.Name, s.Host, s.User, s.Port, s.Key, s.Jump, s.ProxyCommand, s.Options)
+		taken[s.Name] = true
+		if alias := s.Name + "-cmd"; s.RemoteCommand != "" && !hasServer(servers, alias) {
+			b.WriteString("\n")
+			writeCommandBlock(&b, alias, s)
+			taken[alias] = true
+		}
+	}
+	for _, t := range tunnels {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		alias := t.Name
+		for taken[alias] {
+			alias += "-tunnel"
+		}
+		taken[alias] = true
+		writeBlock(&b, alias, t.SSHHost, t.SSHUser, t.SSHPort, t.SSHKey, t.SSHJump, t.SSHProxyCommand, t.SSHOptions)
+		for _, f := range t.Forwards {
+			writeForward(&b, f)
+		}
+	}
+	return b.String()
+}
+
+// writeBlock writes a Host block with the connection settings that are set.
+func writeBlock(b *strings.Builder, alias, host, user string, port int, key string, jump []string, proxyCommand string, opts map[string]string) {
+	fmt.Fprintf(b, "Host %s\n", quote(alias))
+	if host != "" {
+		fmt.Fprintf(b, "  HostName %s\n", host)
+	}
+	if user != "" {
+		fmt.Fprintf(b, "  User %s\n", user)
+	}
+	if port != 0 && port != 22 {
+		fmt.Fprintf(b, "  Port %s\n", strconv.Itoa(port))
+	}
+	if key != "" {
+		fmt.Fprintf(b, "  IdentityFile %s\n", quote(key))
+	}
+	if len(jump) > 0 {
+		fmt.Fprintf(b, "  ProxyJump %s\n", strings.Join(jump, ","))
+	} else if proxyCommand != "" {
+		fmt.Fprintf(b, "  ProxyCommand %s\n", proxyCommand)
+	}
+	for _, name := range SortedOptionNames(opts) {
+		fmt.Fprintf(b, "  %s %s\n", name, opts[name])
+	}
+}
+
+// writeCommandBlock writes a Host block that runs the server's remote command
+// the way `sshh <name>` does. It is kept out of the server's own block: ssh
+// refuses a RemoteCommand alongside a command line, which would break scp,
+// rsync, git and `ssh host cmd` for that host.
+func writeCommandBlock(b *strings.Builder, alias string, s model.Server) {
+	writeBlock(b, alias, s.Host, s.User, s.Port, s.Key, s.Jump, s.ProxyCommand, s.Options)
+	fmt.Fprintf(b, "  RemoteCommand %s\n", s.RemoteCommand)
+	tty := s.RequestTTY
+	if tty == "" {
+		tty = "yes" // sshh passes -t for a remote command
+	}
+	fmt.Fprintf(b, "  RequestTTY %s\n", tty)
+}
+
+// writeForward writes a forward as the matching *Forward directive.
+func writeForward(b *strings.Builder, f model.Forward) {
+	switch f.Type {
+	case model.TunnelLocal:
+		fmt.Fprintf(b, "  LocalForward 127.0.0.1:%d %s:%d\n", f.LocalPort, f.RemoteHost, f.RemotePort)
+	case model.TunnelRemote:
+		fmt.Fprintf(b, "  RemoteForward %d 127.0.0.1:%d\n", f.RemotePort, f.LocalPort)
+	case model.TunnelDynamic:
+		fmt.Fprintf(b, "  DynamicForward 127.0.0.1:%d\n", f.LocalPort)
+	}
+}
+
+// hasServer reports whether a server with the given name is in servers.
+func hasServer(servers []model.Server, name string) bool {
+	for _, s := range servers {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// quote wraps val in double quotes if it contains whitespace.
+func quote(val string) string {
+	if strings.ContainsAny(val, " \t") {
+		return `"` + val + `"`
+	}
+	return val
+}
@@ -0,0 +1,79 @@
+package sshconfig
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"sshh/internal/model"
+)
+
+func TestRenderAliases(t *testing.T) {
+	server := func(name string) model.Server {
+		return model.Server{Name: name, Host: "10.0.0.1", User: "u", Port: 22}
+	}
+	tunnel := func(name string) model.Tunnel {
+		return model.Tunnel{
+			Name: name, SSHHost: "10.0.0.2",
+			Forwards: []model.Forward{{Type: model.TunnelDynamic, LocalPort: 1080}},
+		}
+	}
+	withCommand := server("web")
+	withCommand.RemoteCommand = "tmux new -As main"
+
+	tests := []struct {
+		name    string
+		servers []model.Server
+		tunnels []model.Tunnel
+		want    []string
+	}{
+		{
+			name:    "distinct names",
+			servers: []model.Server{server("web")},
+			tunnels: []model.Tunnel{tunnel("pg")},
+			want:    []string{"web", "pg"},
+		},
+		{
+			name:    "tunnel named like a server",
+			servers: []model.Server{server("db")},
+			tunnels: []model.Tunnel{tunnel("db")},
+			want:    []string{"db", "db-tunnel"},
+		},
+		{
+			name:    "suffixed alias taken by a server",
+			servers: []model.Server{server("db"), server("db-tunnel")},
+			tunnels: []model.Tunnel{tunnel("db")},
+			want:    []string{"db", "db-tunnel", "db-tunnel-tunnel"},
+		},
+		{
+			name:    "suffixed alias taken by a tunnel",
+			servers: []model.Server{server("db")},
+			tunnels: []model.Tunnel{tunnel("db"), tunnel("db-tunnel")},
+			want:    []string{"db", "db-tunnel", "db-tunnel-tunnel"},
+		},
+		{
+			name:    "tunnel named like a command block",
+			servers: []model.Server{withCommand},
+			tunnels: []model.Tunnel{tunnel("web-cmd")},
+			want:    []string{"web", "web-cmd", "web-cmd-tunnel"},
+		},
+		{
+			name:    "command block alias taken by a server",
+			servers: []model.Server{withCommand, server("web-cmd")},
+			want:    []string{"web", "web-cmd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range strings.Split(Render(tt.servers, tt.tunnels), "\n") {
+				if alias, ok := strings.CutPrefix(line, "Host "); ok {
+					got = append(got, alias)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Host aliases = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
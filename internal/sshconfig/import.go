@@ -1,7 +1,6 @@
 package sshconfig
 
 import (
-	"bufio"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -10,86 +9,185 @@ import (
 	"sshh/internal/model"
 )
 
-// Parse reads ~/.ssh/config and returns discovered server entries.
-// It skips wildcard hosts (Host *).
-func Parse() ([]model.Server, error) {
+// Result holds everything discovered in an ssh config.
+type Result struct {
+	Servers []model.Server
+	// Tunnels carries the forwards of hosts that declare any. Each one
+	// references the server of the same name.
+	Tunnels []model.Tunnel
+}
+
+// Parse reads ~/.ssh/config and returns discovered servers and tunnels.
+// Wildcard hosts (Host *) are not servers themselves, but their
+// settings apply to the hosts they match.
+func Parse() (Result, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return Result{}, err
 	}
 	return ParseFile(filepath.Join(home, ".ssh", "config"))
 }
 
-// ParseFile reads the given ssh config file and returns discovered servers.
-func ParseFile(path string) ([]model.Server, error) {
-	f, err := os.Open(path)
+// ParseFile reads the given ssh config file and returns discovered servers
+// and tunnels.
+func ParseFile(path string) (Result, error) {
+	cfg, err := Load(path)
 	if err != nil {
-		return nil, err
+		return Result{}, err
 	}
-	defer f.Close()
 
-	var servers []model.Server
-	var current *model.Server
+	var res Result
+	for _, alias := range cfg.Hosts() {
+		settings := cfg.Resolve(alias)
+		res.Servers = append(res.Servers, toServer(alias, settings, cfg.Own(alias)))
+		if fwds := toForwards(settings); len(fwds) > 0 {
+			res.Tunnels = append(res.Tunnels, model.Tunnel{
+				Name:     alias,
+				Server:   alias,
+				Forwards: fwds,
+			})
+		}
+	}
+	return res, nil
+}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// toServer builds a server from the settings resolved for alias; own holds
+// those set in alias's own Host blocks.
+func toServer(alias string, s, own Settings) model.Server {
+	srv := model.Server{
+		Name: alias,
+		Host: expandHostTokens(unquote(s.Get("hostname")), alias),
+		User: unquote(s.Get("user")),
+		Port: 22,
+	}
+	if p, err := strconv.Atoi(s.Get("port")); err == nil {
+		srv.Port = p
+	}
+	if keys := s.All("identityfile"); len(keys) > 0 {
+		srv.Key = ExpandTilde(unquote(keys[0]))
+	}
+	if jump := unquote(s.Get("proxyjump")); jump != "" && !strings.EqualFold(jump, "none") {
+		for _, hop := range strings.Split(jump, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				srv.Jump = append(srv.Jump, hop)
+			}
+		}
+	}
+	// The command keeps its %h/%p tokens; ssh expands them at connect time.
+	if cmd := s.Get("proxycommand"); cmd != "" && !strings.EqualFold(cmd, "none") {
+		srv.ProxyCommand = cmd
+	}
+	srv.RemoteCommand = s.Get("remotecommand")
+	if tty := strings.ToLower(s.Get("requesttty")); CheckRequestTTY(tty) == nil {
+		srv.RequestTTY = tty
+	}
+	srv.Options = toOptions(s, own)
+	return finalize(srv)
+}
 
-		// Skip comments and empty lines.
-		if line == "" || strings.HasPrefix(line, "#") {
+// toOptions turns the other known directives set in the host's own blocks
+// into server options, so Host * and Match defaults aren't pinned on every
+// server. Each takes the value ssh would use; one that can repeat, such as
+// CertificateFile, keeps only the host's first, since an option holds one.
+func toOptions(s, own Settings) map[string]string {
+	opts := make(map[string]string)
+	for key, vals := range own {
+		canon, ok := CanonicalOption(key)
+		if !ok {
 			continue
 		}
+		if _, ok := fieldOptions[key]; ok {
+			continue
+		}
+		if !multiValued[key] {
+			vals = s[key]
+		}
+		opts[canon] = vals[0]
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
 
-		// Split on first whitespace or '='.
-		key, val := splitDirective(line)
-		key = strings.ToLower(key)
-
-		switch key {
-		case "host":
-			// Finish previous block.
-			if current != nil {
-				servers = append(servers, finalize(*current))
-			}
-			// Skip wildcard patterns.
-			if strings.Contains(val, "*") || strings.Contains(val, "?") {
-				current = nil
-				continue
-			}
-			current = &model.Server{
-				Name: val,
-				Port: 22,
-				User: "root",
-			}
-		case "hostname":
-			if current != nil {
-				current.Host = val
-			}
-		case "user":
-			if current != nil {
-				current.User = val
-			}
-		case "port":
-			if current != nil {
-				if p, err := strconv.Atoi(val); err == nil {
-					current.Port = p
-				}
-			}
-		case "identityfile":
-			if current != nil {
-				current.Key = expandTilde(val)
-			}
+// toForwards converts LocalForward, RemoteForward and DynamicForward
+// directives into forwards. Bind addresses are dropped since sshh always
+// listens on 127.0.0.1, and forms sshh can't express (Unix sockets, remote
+// targets other than localhost, reverse SOCKS) are skipped.
+func toForwards(s Settings) []model.Forward {
+	var fwds []model.Forward
+	for _, val := range s.All("localforward") {
+		args := splitArgs(val)
+		if len(args) != 2 {
+			continue
+		}
+		lport, ok := listenPort(args[0])
+		rhost, rport, ok2 := splitHostPort(args[1])
+		if ok && ok2 {
+			fwds = append(fwds, model.Forward{
+				Type: model.TunnelLocal, LocalPort: lport, RemoteHost: rhost, RemotePort: rport,
+			})
+		}
+	}
+	for _, val := range s.All("remoteforward") {
+		args := splitArgs(val)
+		if len(args) != 2 {
+			continue
+		}
+		rport, ok := listenPort(args[0])
+		lhost, lport, ok2 := splitHostPort(args[1])
+		if ok && ok2 && isLoopback(lhost) {
+			fwds = append(fwds, model.Forward{
+				Type: model.TunnelRemote, LocalPort: lport, RemotePort: rport,
+			})
+		}
+	}
+	for _, val := range s.All("dynamicforward") {
+		if port, ok := listenPort(unquote(val)); ok {
+			fwds = append(fwds, model.Forward{Type: model.TunnelDynamic, LocalPort: port})
 		}
 	}
+	return fwds
+}
+
+// listenPort extracts the port from a [bind_address:]port spec.
+func listenPort(spec string) (int, bool) {
+	if i := strings.LastIndexAny(spec, ":/"); i != -1 {
+		spec = spec[i+1:]
+	}
+	port, err := strconv.Atoi(spec)
+	return port, err == nil && port > 0
+}
 
-	// Don't forget the last block.
-	if current != nil {
-		servers = append(servers, finalize(*current))
+// splitHostPort splits host:port, [v6addr]:port or host/port.
+func splitHostPort(spec string) (string, int, bool) {
+	i := strings.LastIndexAny(spec, ":/")
+	if i <= 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(spec[i+1:])
+	if err != nil || port <= 0 {
+		return "", 0, false
 	}
+	// IPv6 brackets are kept; ssh -L needs them.
+	return spec[:i], port, true
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// isLoopback reports whether host names the local machine.
+func isLoopback(host string) bool {
+	switch strings.ToLower(strings.Trim(host, "[]")) {
+	case "localhost", "127.0.0.1", "::1":
+		return true
 	}
-	return servers, nil
+	return false
+}
+
+// expandHostTokens expands the %h and %% tokens allowed in HostName.
+func expandHostTokens(val, alias string) string {
+	if !strings.Contains(val, "%") {
+		return val
+	}
+	return strings.NewReplacer("%%", "%", "%h", alias).Replace(val)
 }
 
 // finalize ensures required fields have defaults.
@@ -97,24 +195,26 @@ func finalize(s model.Server) model.Server {
 	if s.Host == "" {
 		s.Host = s.Name
 	}
+	if s.User == "" {
+		s.User = "root"
+	}
 	return s
 }
 
 // splitDirective splits "Key value" or "Key=value" into (key, value).
+// Only the separator after the key counts; '=' inside the value is kept.
 func splitDirective(line string) (string, string) {
-	// Try '=' first.
-	if idx := strings.Index(line, "="); idx != -1 {
-		return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
-	}
-	parts := strings.SplitN(line, " ", 2)
-	if len(parts) == 2 {
-		return parts[0], strings.TrimSpace(parts[1])
+	end := strings.IndexAny(line, " \t=")
+	if end == -1 {
+		return line, ""
 	}
-	return parts[0], ""
+	key, rest := line[:end], strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	return key, strings.TrimSpace(rest)
 }
 
-// expandTilde replaces a leading ~ with the user's home directory.
-func expandTilde(path string) string {
+// ExpandTilde replaces a leading ~/ with the user's home directory.
+func ExpandTilde(path string) string {
 	if strings.HasPrefix(path, "~/") {
 		if home, err := os.UserHomeDir(); err == nil {
 			return filepath.Join(home, path[2:])
@@ -0,0 +1,34 @@
+package sshconfig
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileOptions(t *testing.T) {
+	res, err := ParseFile(filepath.Join("testdata", "import", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"web": {
+			"ForwardAgent":    "yes",
+			"CertificateFile": "~/.ssh/web-cert.pub",
+			"SendEnv":         "LANG LC_*",
+		},
+		"db": {
+			"Compression":    "yes",
+			"IdentitiesOnly": "yes",
+		},
+		"bare": nil,
+	}
+	if len(res.Servers) != len(want) {
+		t.Fatalf("servers = %+v, want %d", res.Servers, len(want))
+	}
+	for _, s := range res.Servers {
+		if !maps.Equal(s.Options, want[s.Name]) {
+			t.Errorf("%s: options = %v, want %v", s.Name, s.Options, want[s.Name])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sshconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// knownOptions lists the client options documented in ssh_config(5).
+var knownOptions = []string{
+	"AddKeysToAgent", "AddressFamily", "BatchMode", "BindAddress",
+	"BindInterface", "CanonicalDomains", "CanonicalizeFallbackLocal",
+	"CanonicalizeHostname", "CanonicalizeMaxDots",
+	"CanonicalizePermittedCNAMEs", "CASignatureAlgorithms",
+	"CertificateFile", "ChannelTimeout", "CheckHostIP", "Ciphers",
+	"ClearAllForwardings", "Compression", "ConnectionAttempts",
+	"ConnectTimeout", "ControlMaster", "ControlPath", "ControlPersist",
+	"DynamicForward", "EnableEscapeCommandline", "EnableSSHKeysign",
+	"EscapeChar", "ExitOnForwardFailure", "FingerprintHash",
+	"ForkAfterAuthentication", "ForwardAgent", "ForwardX11",
+	"ForwardX11Timeout", "ForwardX11Trusted", "GatewayPorts",
+	"GlobalKnownHostsFile", "GSSAPIAuthentication",
+	"GSSAPIDelegateCredentials", "HashKnownHosts", "HostbasedAcceptedAlgorithms",
+	"HostbasedAuthentication", "HostKeyAlgorithms", "HostKeyAlias",
+	"HostName", "IdentitiesOnly", "IdentityAgent", "IdentityFile",
+	"IgnoreUnknown", "IPQoS", "KbdInteractiveAuthentication",
+	"KbdInteractiveDevices", "KexAlgorithms", "KnownHostsCommand",
+	"LocalCommand", "LocalForward", "LogLevel", "LogVerbose", "MACs",
+	"NoHostAuthenticationForLocalhost", "NumberOfPasswordPrompts",
+	"ObscureKeystrokeTiming", "PasswordAuthentication", "PermitLocalCommand",
+	"PermitRemoteOpen", "PKCS11Provider", "Port", "PreferredAuthentications",
+	"ProxyCommand", "ProxyJump", "ProxyUseFdpass", "PubkeyAcceptedAlgorithms",
+	"PubkeyAuthentication", "RekeyLimit", "RemoteCommand", "RemoteForward",
+	"RequestTTY", "RequiredRSASize", "RevokedHostKeys", "SecurityKeyProvider",
+	"SendEnv", "ServerAliveCountMax", "ServerAliveInterval", "SessionType",
+	"SetEnv", "StdinNull", "StreamLocalBindMask", "StreamLocalBindUnlink",
+	"StrictHostKeyChecking", "SyslogFacility", "Tag", "TCPKeepAlive",
+	"Tunnel", "TunnelDevice", "UpdateHostKeys", "User", "UserKnownHostsFile",
+	"VerifyHostKeyDNS", "VisualHostKey", "XAuthLocation",
+}
+
+// fieldOptions are options that have a dedicated server or tunnel field
+// and so can't be set through Options.
+var fieldOptions = map[string]string{
+	"hostname":       "host",
+	"user":           "user",
+	"port":           "port",
+	"identityfile":   "key",
+	"proxyjump":      "jump",
+	"proxycommand":   "proxy command",
+	"remotecommand":  "remote command",
+	"requesttty":     "request tty",
+	"localforward":   "tunnel forwards",
+	"remoteforward":  "tunnel forwards",
+	"dynamicforward": "tunnel forwards",
+}
+
+var canonicalOptions = func() map[string]string {
+	m := make(map[string]string, len(knownOptions))
+	for _, name := range knownOptions {
+		m[strings.ToLower(name)] = name
+	}
+	return m
+}()
+
+// CanonicalOption returns the documented spelling of an ssh option name,
+// matched case-insensitively, and whether OpenSSH knows it.
+func CanonicalOption(name string) (string, bool) {
+	canon, ok := canonicalOptions[strings.ToLower(name)]
+	return canon, ok
+}
+
+// ValidateOptions checks option names against the known OpenSSH options and
+// returns a copy with the names canonicalized. Options that have a dedicated
+// field (HostName, Port, ProxyJump, ...) are rejected.
+func ValidateOptions(opts map[string]string) (map[string]string, error) {
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(opts))
+	for name, val := range opts {
+		canon, ok := CanonicalOption(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown ssh option %q", name)
+		}
+		if field, ok := fieldOptions[strings.ToLower(name)]; ok {
+			return nil, fmt.Errorf("ssh option %s: set the %s field instead", canon, field)
+		}
+		if strings.TrimSpace(val) == "" {
+			return nil, fmt.Errorf("ssh option %s has no value", canon)
+		}
+		out[canon] = val
+	}
+	return out, nil
+}
+
+// CheckRequestTTY validates a RequestTTY value; empty means unset.
+func CheckRequestTTY(val string) error {
+	switch val {
+	case "", "yes", "no", "force", "auto":
+		return nil
+	}
+	return fmt.Errorf("request tty must be yes, no, force or auto, not %q", val)
+}
+
+// optionSep matches a ";" that starts another Key=Value pair.
+var optionSep = regexp.MustCompile(`;\s*[A-Za-z][A-Za-z0-9]*\s*=`)
+
+// ParseOptions parses "Key=Value; Key=Value" into a validated option map.
+// Only a ";" followed by another Key= separates options, so values such as
+// LocalCommand may contain one.
+func ParseOptions(raw string) (map[string]string, error) {
+	var pairs []string
+	for {
+		loc := optionSep.FindStringIndex(raw)
+		if loc == nil {
+			break
+		}
+		pairs = append(pairs, raw[:loc[0]])
+		raw = raw[loc[0]+1:]
+	}
+	// A trailing ";" ends the list rather than the last value.
+	raw = strings.TrimSuffix(strings.TrimSpace(raw), ";")
+	return ParseOptionPairs(append(pairs, raw))
+}
+
+// ParseOptionPairs parses one "Key=Value" (or "Key Value") pair per entry
+// into a validated option map.
+func ParseOptionPairs(pairs []string) (map[string]string, error) {
+	opts := make(map[string]string)
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val := splitDirective(pair)
+		if val == "" {
+			return nil, fmt.Errorf("ssh option %q: expected Key=Value", pair)
+		}
+		opts[key] = val
+	}
+	return ValidateOptions(opts)
+}
+
+// FormatOptions renders options as "Key=Value; Key=Value", sorted by name.
+func FormatOptions(opts map[string]string) string {
+	var pairs []string
+	for _, name := range SortedOptionNames(opts) {
+		pairs = append(pairs, name+"="+opts[name])
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// SortedOptionNames returns the option names in a stable order.
+func SortedOptionNames(opts map[string]string) []string {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,53 @@
+package sshconfig
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "forwardagent=yes", want: map[string]string{"ForwardAgent": "yes"}},
+		{in: "ForwardAgent=yes; Compression=yes;", want: map[string]string{"ForwardAgent": "yes", "Compression": "yes"}},
+		{in: "ForwardAgent yes", want: map[string]string{"ForwardAgent": "yes"}},
+		{in: "Ciphers=aes128-ctr,aes256-ctr", want: map[string]string{"Ciphers": "aes128-ctr,aes256-ctr"}},
+
+		// A ";" not followed by Key= belongs to the value.
+		{in: "LocalCommand=echo a; echo b", want: map[string]string{"LocalCommand": "echo a; echo b"}},
+		{
+			in:   "LocalCommand=echo a; echo b; PermitLocalCommand=yes",
+			want: map[string]string{"LocalCommand": "echo a; echo b", "PermitLocalCommand": "yes"},
+		},
+		{in: "SetEnv=A=1", want: map[string]string{"SetEnv": "A=1"}},
+
+		{in: "ForwardAgent=yes; Bogus=1", wantErr: true},
+		{in: "HostName=example.com", wantErr: true},
+		{in: "ForwardAgent", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOptions(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOptions(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("ParseOptions(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOptionsRoundTrip(t *testing.T) {
+	opts := map[string]string{"LocalCommand": "echo a; echo b", "PermitLocalCommand": "yes"}
+	got, err := ParseOptions(FormatOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got, opts) {
+		t.Errorf("round trip = %v, want %v", got, opts)
+	}
+}
@@ -0,0 +1,364 @@
+package sshconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxIncludeDepth matches OpenSSH's limit on nested Include directives.
+const maxIncludeDepth = 16
+
+// multiValued lists directives that accumulate across blocks instead of
+// following first-match-wins.
+var multiValued = map[string]bool{
+	"identityfile":    true,
+	"certificatefile": true,
+	"localforward":    true,
+	"remoteforward":   true,
+	"dynamicforward":  true,
+	"sendenv":         true,
+	"setenv":          true,
+}
+
+// directive is one "Key value" line.
+type directive struct {
+	key   string // lower-cased
+	value string // raw value, quotes intact
+}
+
+// block is a run of directives guarded by a Host or Match condition.
+type block struct {
+	host    []string   // Host patterns; nil for Match blocks
+	match   [][]string // Match criteria as (keyword, [arg]) pairs
+	isMatch bool
+	dirs    []directive
+}
+
+// Config is a parsed ssh_config with Includes expanded in place.
+type Config struct {
+	blocks []block
+}
+
+// Settings holds the directives that apply to one host, keyed by
+// lower-cased directive name.
+type Settings map[string][]string
+
+// Get returns the effective value of a directive, or "".
+func (s Settings) Get(key string) string {
+	if v := s[strings.ToLower(key)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// All returns every value of a multi-valued directive, in config order.
+func (s Settings) All(key string) []string {
+	return s[strings.ToLower(key)]
+}
+
+// Load parses an ssh_config file, following Include directives. Relative
+// Include paths are resolved against the directory of path, which for the
+// user config is ~/.ssh as in OpenSSH.
+func Load(path string) (*Config, error) {
+	p := &parser{baseDir: filepath.Dir(path)}
+	// Lines before the first Host/Match apply to every host.
+	p.blocks = []block{{host: []string{"*"}}}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+	return &Config{blocks: p.blocks}, nil
+}
+
+type parser struct {
+	baseDir string
+	blocks  []block
+}
+
+func (p *parser) current() *block {
+	return &p.blocks[len(p.blocks)-1]
+}
+
+func (p *parser) parseFile(path string, depth int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Host/Match lines in an included file don't leak into the parent:
+	// once it ends, the parent's remaining lines keep the parent's condition.
+	parent := *p.current()
+	parent.dirs = nil
+	startLen := len(p.blocks)
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip comments and empty lines.
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, val := splitDirective(line)
+		key = strings.ToLower(key)
+
+		switch key {
+		case "host":
+			p.blocks = append(p.blocks, block{host: splitArgs(val)})
+		case "match":
+			p.blocks = append(p.blocks, block{isMatch: true, match: parseMatch(splitArgs(val))})
+		case "include":
+			if depth+1 > maxIncludeDepth {
+				return fmt.Errorf("%s:%d: too many nested includes", path, lineNo)
+			}
+			for _, pattern := range splitArgs(val) {
+				if err := p.include(pattern, depth+1); err != nil {
+					return err
+				}
+			}
+		default:
+			b := p.current()
+			b.dirs = append(b.dirs, directive{key: key, value: val})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if depth > 0 && len(p.blocks) > startLen {
+		p.blocks = append(p.blocks, parent)
+	}
+	return nil
+}
+
+// include parses every file matching pattern, in lexical order.
+// Missing files are ignored, as OpenSSH does.
+func (p *parser) include(pattern string, depth int) error {
+	pattern = ExpandTilde(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(p.baseDir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+	for _, m := range matches {
+		if info, err := os.Stat(m); err != nil || info.IsDir() {
+			continue
+		}
+		if err := p.parseFile(m, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMatch groups Match arguments into criteria. Keywords "all",
+// "canonical" and "final" take no argument; the rest take one.
+func parseMatch(args []string) [][]string {
+	var out [][]string
+	for i := 0; i < len(args); i++ {
+		kw := strings.ToLower(args[i])
+		switch strings.TrimPrefix(kw, "!") {
+		case "all", "canonical", "final":
+			out = append(out, []string{kw})
+		default:
+			if i+1 < len(args) {
+				out = append(out, []string{kw, args[i+1]})
+				i++
+			} else {
+				out = append(out, []string{kw, ""})
+			}
+		}
+	}
+	return out
+}
+
+// Hosts returns every concrete host alias named on a Host line, in order.
+// Wildcard and negated patterns are not hosts in their own right.
+func (c *Config) Hosts() []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, b := range c.blocks {
+		for _, pat := range b.host {
+			if strings.ContainsAny(pat, "*?!") || seen[pat] {
+				continue
+			}
+			seen[pat] = true
+			hosts = append(hosts, pat)
+		}
+	}
+	return hosts
+}
+
+// Resolve returns the settings that apply to alias. As in OpenSSH, the first
+// value seen for a directive wins, except for multi-valued directives such
+// as IdentityFile and LocalForward, which accumulate.
+func (c *Config) Resolve(alias string) Settings {
+	s := make(Settings)
+	for _, b := range c.blocks {
+		if !c.matches(b, alias, s) {
+			continue
+		}
+		for _, d := range b.dirs {
+			if multiValued[d.key] {
+				s[d.key] = append(s[d.key], d.value)
+			} else if _, set := s[d.key]; !set {
+				s[d.key] = []string{d.value}
+			}
+		}
+	}
+	return s
+}
+
+// Own returns the directives set in the Host blocks that name alias itself,
+// leaving out those it only gets from wildcard, Match and global lines. Values
+// are collected the same way as in Resolve.
+func (c *Config) Own(alias string) Settings {
+	s := make(Settings)
+	for _, b := range c.blocks {
+		if !slices.Contains(b.host, alias) || !matchPatternList(b.host, alias) {
+			continue
+		}
+		for _, d := range b.dirs {
+			if multiValued[d.key] {
+				s[d.key] = append(s[d.key], d.value)
+			} else if _, set := s[d.key]; !set {
+				s[d.key] = []string{d.value}
+			}
+		}
+	}
+	return s
+}
+
+// matches reports whether a block applies to alias given the settings
+// resolved so far (Match host and user look at those).
+func (c *Config) matches(b block, alias string, s Settings) bool {
+	if !b.isMatch {
+		return matchPatternList(b.host, alias)
+	}
+
+	hostname := s.Get("hostname")
+	if hostname == "" {
+		hostname = alias
+	}
+	for _, crit := range b.match {
+		kw, negate := strings.CutPrefix(crit[0], "!")
+		var ok bool
+		switch kw {
+		case "all":
+			ok = true
+		case "host":
+			ok = matchPatternList(strings.Split(crit[1], ","), hostname)
+		case "originalhost":
+			ok = matchPatternList(strings.Split(crit[1], ","), alias)
+		case "user":
+			ok = matchPatternList(strings.Split(crit[1], ","), s.Get("user"))
+		case "localuser":
+			if u, err := user.Current(); err == nil {
+				ok = matchPatternList(strings.Split(crit[1], ","), u.Username)
+			}
+		default:
+			// exec, canonical, final, tagged, ...: not evaluated when
+			// importing, so treat the block as not applying.
+			return false
+		}
+		if ok == negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPatternList reports whether s matches a pattern list: at least one
+// positive pattern matches and no negated (!) pattern does.
+func matchPatternList(patterns []string, s string) bool {
+	matched := false
+	for _, pat := range patterns {
+		if neg, ok := strings.CutPrefix(pat, "!"); ok {
+			if matchPattern(neg, s) {
+				return false
+			}
+			continue
+		}
+		if matchPattern(pat, s) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPattern matches s against an ssh_config pattern, where * matches any
+// run of characters and ? matches exactly one. Matching is case-insensitive.
+func matchPattern(pattern, s string) bool {
+	return matchFold([]rune(strings.ToLower(pattern)), []rune(strings.ToLower(s)))
+}
+
+func matchFold(p, s []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for i := 0; i <= len(s); i++ {
+				if matchFold(p[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || p[0] != s[0] {
+				return false
+			}
+		}
+		p, s = p[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// splitArgs splits a value on whitespace, honouring double quotes.
+func splitArgs(val string) []string {
+	var args []string
+	var cur strings.Builder
+	inQuote, have := false, false
+	for _, r := range val {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			have = true
+		case (r == ' ' || r == '\t') && !inQuote:
+			if have {
+				args = append(args, cur.String())
+				cur.Reset()
+				have = false
+			}
+		default:
+			cur.WriteRune(r)
+			have = true
+		}
+	}
+	if have {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// unquote strips one pair of surrounding double quotes.
+func unquote(val string) string {
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
@@ -0,0 +1,99 @@
+package sshconfig
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func loadFixture(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load(filepath.Join("testdata", "include", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestHosts(t *testing.T) {
+	got := loadFixture(t).Hosts()
+	want := []string{
+		"app", "deep", // conf.d/*.conf, then the file it includes
+		"api.prod", "bastion.prod", // extra.conf
+		"web1", "web2", "web3", "db",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Hosts() = %q, want %q", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cfg := loadFixture(t)
+	tests := []struct {
+		name  string
+		alias string
+		key   string
+		want  []string // nil: not set
+	}{
+		// Defaults from before the first Host and from Host *.
+		{"global line", "web1", "ServerAliveInterval", []string{"30"}},
+		{"host * default", "web2", "Compression", []string{"no"}},
+		{"host * fills gaps", "app", "User", []string{"root"}},
+
+		// Host a b c.
+		{"multi-pattern first", "web1", "User", []string{"deploy"}},
+		{"multi-pattern last", "web3", "Port", []string{"2222"}},
+
+		// Included files, via a glob and a relative path.
+		{"glob include", "app", "HostName", []string{"app.example.com"}},
+		{"nested include", "deep", "HostName", []string{"deep.example.com"}},
+		{"relative include", "api.prod", "HostName", []string{"%h.example.com"}},
+
+		// Negation.
+		{"wildcard", "api.prod", "User", []string{"ops"}},
+		{"negated", "bastion.prod", "User", []string{"root"}},
+
+		// First match wins; multi-valued directives accumulate.
+		{"first user wins", "db", "User", []string{"postgres"}},
+		{"first port wins", "db", "Port", []string{"5433"}},
+		{"identity files accumulate", "db", "IdentityFile", []string{
+			"~/.ssh/db_second", "~/.ssh/db_match", "~/.ssh/id_default",
+		}},
+
+		// Match, seeing the settings resolved before it.
+		{"match host uses HostName", "db", "ForwardAgent", []string{"yes"}},
+		{"match host misses", "app", "ForwardAgent", nil},
+		{"match originalhost and user", "web1", "IdentityFile", []string{"~/.ssh/id_default"}},
+		{"match user misses", "db", "Compression", []string{"no"}},
+		{"negated match skips", "web2", "LogLevel", nil},
+		{"negated match applies", "app", "LogLevel", []string{"QUIET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.Resolve(tt.alias).All(tt.key)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Resolve(%q).All(%q) = %q, want %q", tt.alias, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"a b c", []string{"a", "b", "c"}},
+		{"  a \t b  ", []string{"a", "b"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`a"b c"d`, []string{"ab cd"}},
+		{`"" x`, []string{"", "x"}},
+		{`"~/My Keys/id"`, []string{"~/My Keys/id"}},
+	}
+	for _, tt := range tests {
+		if got := splitArgs(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
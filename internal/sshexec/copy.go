@@ -0,0 +1,76 @@
+package sshexec
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"sshh/internal/model"
+)
+
+// Transfer describes a file copy between this machine and a saved server.
+type Transfer struct {
+	Local  string
+	Remote string
+	Upload bool // true copies Local to the server, false copies Remote here
+}
+
+// CopyCommand builds an scp command for the transfer, using the same port,
+// key and jump hosts as Connect. Copies are recursive so directories work too.
+// The caller wires up stdio; scp shows its progress meter on a terminal.
+func CopyCommand(s model.Server, servers []model.Server, tr Transfer) (*exec.Cmd, error) {
+	scpBin, err := exec.LookPath("scp")
+	if err != nil {
+		return nil, fmt.Errorf("scp not found in PATH: %w", err)
+	}
+
+	args, err := connOptions(s, servers, "-P")
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "-r")
+
+	remote := remoteSpec(s, tr.Remote)
+	if tr.Upload {
+		args = append(args, tr.Local, remote)
+	} else {
+		args = append(args, remote, tr.Local)
+	}
+	return exec.Command(scpBin, args...), nil
+}
+
+// SFTPCommand builds an interactive sftp session to the server.
+func SFTPCommand(s model.Server, servers []model.Server) (*exec.Cmd, error) {
+	sftpBin, err := exec.LookPath("sftp")
+	if err != nil {
+		return nil, fmt.Errorf("sftp not found in PATH: %w", err)
+	}
+
+	args, err := connOptions(s, servers, "-P")
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, bracketHost(destination(s)))
+	return exec.Command(sftpBin, args...), nil
+}
+
+// remoteSpec formats [user@]host:path for scp. IPv6 hosts need brackets so
+// their colons aren't taken as the path separator.
+func remoteSpec(s model.Server, path string) string {
+	return bracketHost(destination(s)) + ":" + path
+}
+
+// bracketHost wraps an IPv6 literal in brackets, keeping any user@ prefix.
+func bracketHost(dest string) string {
+	user, host, found := strings.Cut(dest, "@")
+	if !found {
+		host, user = user, ""
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		host = "[" + host + "]"
+	}
+	if user != "" {
+		return user + "@" + host
+	}
+	return host
+}
@@ -1,38 +1,231 @@
 package sshexec
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"sshh/internal/model"
+	"sshh/internal/sshconfig"
 )
 
+// ExitError reports that an ssh session ended with a non-zero status.
+// ssh has already printed the reason, so callers usually just exit with Code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("ssh exited with status %d", e.Code)
+}
+
+// Options are the settings that apply to every connection.
+type Options struct {
+	Hooks model.Hooks // global hooks, run around the server's own
+	Child bool        // run ssh as a child process instead of exec'ing it
+
+	// Started, if set, is called once the pre-connect hooks have passed
+	// and ssh is about to start.
+	Started func()
+}
+
 // Connect replaces the current process with an ssh connection to the server.
-// This function does not return on success.
-func Connect(s model.Server) error {
+// Jump hosts are resolved against servers, so a hop may name another saved
+// server. The server's remote command, if any, runs instead of a login shell.
+// The global and server pre-connect hooks run first. If any post-connect
+// hook is set, or opts.Child, ssh runs as a child instead so sshh can carry
+// on after it; a non-zero exit is then returned as an *ExitError.
+// Otherwise this function does not return on success.
+func Connect(s model.Server, servers []model.Server, opts Options) error {
+	return runSSH(s, servers, s.RemoteCommand, ttyArgs(s, s.RemoteCommand != ""), opts, Hooks{opts.Hooks, s.Hooks})
+}
+
+// ConnectCommand is like Connect but runs command instead of the server's
+// remote command. As with plain ssh, no tty is requested unless the server's
+// request_tty asks for one.
+func ConnectCommand(s model.Server, servers []model.Server, command string, opts Options) error {
+	return runSSH(s, servers, command, ttyArgs(s, false), opts, Hooks{opts.Hooks, s.Hooks})
+}
+
+// CommandLine returns the ssh command Connect would run for the server,
+// quoted for a POSIX shell. Hooks are not included.
+func CommandLine(s model.Server, servers []model.Server) (string, error) {
+	args, err := connectArgs(s, servers, s.RemoteCommand, ttyArgs(s, s.RemoteCommand != ""))
+	if err != nil {
+		return "", err
+	}
+	words := []string{"ssh"}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " "), nil
+}
+
+// runSSH runs ssh to the server, running command if it is set.
+func runSSH(s model.Server, servers []model.Server, command string, tty []string, opts Options, hooks Hooks) error {
 	sshBin, err := exec.LookPath("ssh")
 	if err != nil {
 		return fmt.Errorf("ssh not found in PATH: %w", err)
 	}
 
-	args := []string{"ssh"}
+	args, err := connectArgs(s, servers, command, tty)
+	if err != nil {
+		return err
+	}
+
+	env := ServerEnv(s)
+	if err := hooks.RunPre(env, os.Stdin, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+	if opts.Started != nil {
+		opts.Started()
+	}
+
+	if !opts.Child && !hooks.hasPost() {
+		// Replace current process with ssh.
+		return syscall.Exec(sshBin, append([]string{"ssh"}, args...), os.Environ())
+	}
+
+	code, err := runChild(exec.Command(sshBin, args...))
+	if err != nil {
+		return err
+	}
+	hooks.RunPost(env, code, os.Stdin, os.Stdout, os.Stderr)
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// connectArgs builds the ssh arguments for an interactive connection.
+func connectArgs(s model.Server, servers []model.Server, command string, tty []string) ([]string, error) {
+	args, err := serverArgs(s, servers)
+	if err != nil {
+		return nil, err
+	}
+	args = append(tty, args...)
+	if command != "" {
+		args = append(args, "--", command)
+	}
+	return args, nil
+}
+
+// runChild runs ssh attached to the terminal and returns its exit code.
+// The terminal delivers Ctrl+C to ssh directly; it is caught here so sshh
+// survives to do its own cleanup.
+func runChild(cmd *exec.Cmd) (int, error) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return code, nil
+		}
+		return 255, nil // killed by a signal
+	}
+	return 0, err
+}
+
+// ttyArgs maps the server's request_tty to ssh flags. When it is unset and
+// wantTTY is true, a tty is forced with -t: a configured remote command is
+// usually interactive (tmux, a shell) and ssh wouldn't allocate one.
+func ttyArgs(s model.Server, wantTTY bool) []string {
+	if s.RequestTTY != "" {
+		return []string{"-o", "RequestTTY=" + s.RequestTTY}
+	}
+	if wantTTY {
+		return []string{"-t"}
+	}
+	return nil
+}
+
+// RemoteCommand builds an ssh command that runs command on the server and
+// exits. BatchMode is set so a host that would prompt for a password fails
+// instead of hanging. The caller wires up stdio.
+func RemoteCommand(s model.Server, servers []model.Server, command string) (*exec.Cmd, error) {
+	sshBin, err := exec.LookPath("ssh")
+	if err != nil {
+		return nil, fmt.Errorf("ssh not found in PATH: %w", err)
+	}
+
+	args, err := serverArgs(s, servers)
+	if err != nil {
+		return nil, err
+	}
+	args = append([]string{"-o", "BatchMode=yes"}, args...)
+	args = append(args, "--", command)
+	return exec.Command(sshBin, args...), nil
+}
+
+// serverArgs builds the ssh options and destination for a server,
+// not including the program name.
+func serverArgs(s model.Server, servers []model.Server) ([]string, error) {
+	args, err := connOptions(s, servers, "-p")
+	if err != nil {
+		return nil, err
+	}
+	return append(args, destination(s)), nil
+}
+
+// connOptions builds the connection options shared by ssh, scp and sftp.
+// portFlag is "-p" for ssh and "-P" for scp/sftp.
+func connOptions(s model.Server, servers []model.Server, portFlag string) ([]string, error) {
+	args := optionArgs(s.Options)
 
 	if s.Port != 0 && s.Port != 22 {
-		args = append(args, "-p", strconv.Itoa(s.Port))
+		args = append(args, portFlag, strconv.Itoa(s.Port))
 	}
 	if s.Key != "" {
 		args = append(args, "-i", s.Key)
 	}
 
-	target := s.Host
-	if s.User != "" {
-		target = s.User + "@" + s.Host
+	hops, err := resolveJumps(s.Name, s.Jump, servers)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, proxyOptions(hops, s.ProxyCommand)...)
+	return args, nil
+}
+
+// optionArgs turns an options map into -o Key=Value arguments in a stable
+// order.
+func optionArgs(opts map[string]string) []string {
+	var args []string
+	for _, name := range sshconfig.SortedOptionNames(opts) {
+		args = append(args, "-o", name+"="+opts[name])
+	}
+	return args
+}
+
+// shellQuote quotes arg for a POSIX shell if it contains anything special.
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
 	}
-	args = append(args, target)
+	if strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+	}) == -1 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
 
-	// Replace current process with ssh.
-	return syscall.Exec(sshBin, args, os.Environ())
+// destination returns the [user@]host of a server.
+func destination(s model.Server) string {
+	if s.User != "" {
+		return s.User + "@" + s.Host
+	}
+	return s.Host
 }
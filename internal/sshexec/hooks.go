@@ -0,0 +1,100 @@
+package sshexec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"sshh/internal/model"
+)
+
+// Hooks are the hooks that apply to one connection, outermost (global)
+// first.
+type Hooks []model.Hooks
+
+// RunPre runs the pre-connect hooks in order. The first failure aborts the
+// connection.
+func (h Hooks) RunPre(env []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	for _, hk := range h {
+		if hk.Pre == "" {
+			continue
+		}
+		if err := runHook(hk.Pre, env, stdin, stdout, stderr); err != nil {
+			return fmt.Errorf("pre-connect hook %q failed: %w", hk.Pre, err)
+		}
+	}
+	return nil
+}
+
+// RunPost runs the post-connect hooks innermost first, with the session's
+// exit code in SSHH_EXIT_CODE. The session is already over, so failures are
+// only reported.
+func (h Hooks) RunPost(env []string, exitCode int, stdin io.Reader, stdout, stderr io.Writer) {
+	env = append(env, "SSHH_EXIT_CODE="+strconv.Itoa(exitCode))
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].Post == "" {
+			continue
+		}
+		if err := runHook(h[i].Post, env, stdin, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "post-connect hook %q failed: %v\n", h[i].Post, err)
+		}
+	}
+}
+
+// HasPre reports whether any pre-connect hook is set.
+func (h Hooks) HasPre() bool {
+	for _, hk := range h {
+		if hk.Pre != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPost reports whether any post-connect hook is set.
+func (h Hooks) hasPost() bool {
+	for _, hk := range h {
+		if hk.Post != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runHook runs command with sh, adding env to sshh's own environment.
+func runHook(command string, env []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// ServerEnv returns the SSHH_* variables that describe a server connection
+// to hooks.
+func ServerEnv(s model.Server) []string {
+	return hookEnv("server", s.Name, s.Host, s.User, s.Port, s.Key)
+}
+
+// TunnelEnv returns the SSHH_* variables that describe a tunnel to hooks.
+// t must already have any server reference resolved.
+func TunnelEnv(t model.Tunnel) []string {
+	return hookEnv("tunnel", t.Name, t.SSHHost, t.SSHUser, t.SSHPort, t.SSHKey)
+}
+
+func hookEnv(kind, name, host, user string, port int, key string) []string {
+	if port == 0 {
+		port = 22
+	}
+	return []string{
+		"SSHH_KIND=" + kind,
+		"SSHH_NAME=" + name,
+		"SSHH_HOST=" + host,
+		"SSHH_USER=" + user,
+		"SSHH_PORT=" + strconv.Itoa(port),
+		"SSHH_KEY=" + key,
+	}
+}
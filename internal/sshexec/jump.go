@@ -0,0 +1,158 @@
+package sshexec
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"sshh/internal/model"
+)
+
+// hop is one jump host in a resolved chain.
+type hop struct {
+	spec   string        // [user@]host[:port], as ssh -J takes it
+	server *model.Server // the saved server, or nil for a literal hop
+}
+
+// resolveJumps expands a jump list into the full ordered hop chain.
+// Entries naming a saved server are replaced by that server, preceded by its
+// own jump chain; anything else is passed through as a literal [user@]host[:port].
+// origin is the name of the server being connected to (empty for tunnels) and
+// is treated as part of the chain so a hop leading back to it is a cycle.
+func resolveJumps(origin string, jumps []string, servers []model.Server) ([]hop, error) {
+	var hops []hop
+	var path []string
+	if origin != "" {
+		path = append(path, origin)
+	}
+
+	var walk func(jumps []string) error
+	walk = func(jumps []string) error {
+		for _, j := range jumps {
+			srv := findServer(servers, j)
+			if srv == nil {
+				hops = append(hops, hop{spec: j})
+				continue
+			}
+			for _, name := range path {
+				if name == srv.Name {
+					chain := append(append([]string{}, path...), srv.Name)
+					return fmt.Errorf("jump host cycle: %s", strings.Join(chain, " → "))
+				}
+			}
+			path = append(path, srv.Name)
+			if err := walk(srv.Jump); err != nil {
+				return err
+			}
+			path = path[:len(path)-1]
+			hops = append(hops, hop{spec: hopSpec(*srv), server: srv})
+		}
+		return nil
+	}
+
+	if err := walk(jumps); err != nil {
+		return nil, err
+	}
+	return hops, nil
+}
+
+// proxyOptions returns the ssh options that route through a resolved hop
+// chain, or otherwise through proxyCommand. ssh only honours one of the two.
+// Hops are passed with -J unless a saved hop has a key, options or a proxy
+// command of its own, which -J can't carry; the chain is then built as
+// nested ProxyCommands.
+func proxyOptions(hops []hop, proxyCommand string) []string {
+	for _, h := range hops {
+		if h.server != nil && (h.server.Key != "" || len(h.server.Options) > 0 || h.server.ProxyCommand != "") {
+			return []string{"-o", "ProxyCommand=" + proxyChain(hops)}
+		}
+	}
+	if len(hops) > 0 {
+		specs := make([]string, len(hops))
+		for i, h := range hops {
+			specs[i] = h.spec
+		}
+		return []string{"-J", strings.Join(specs, ",")}
+	}
+	if proxyCommand != "" {
+		return []string{"-o", "ProxyCommand=" + proxyCommand}
+	}
+	return nil
+}
+
+// proxyChain builds a ProxyCommand that reaches the last hop through the ones
+// before it, each an `ssh -W %h:%p` with the hop's own port, key and options.
+// The first hop uses its proxy command, if it has one. Each command runs in
+// the ssh started by the next, which expands its % tokens first, so they are
+// doubled once per level.
+func proxyChain(hops []hop) string {
+	prev := ""
+	for i, h := range hops {
+		args := []string{"ssh"}
+		dest := h.spec
+		if h.server != nil {
+			args = append(args, optionArgs(h.server.Options)...)
+			if h.server.Port != 0 && h.server.Port != 22 {
+				args = append(args, "-p", strconv.Itoa(h.server.Port))
+			}
+			if h.server.Key != "" {
+				args = append(args, "-i", h.server.Key)
+			}
+			if i == 0 && h.server.ProxyCommand != "" {
+				prev = h.server.ProxyCommand
+			}
+			dest = destination(*h.server)
+		} else if d, port := splitHopSpec(h.spec); port != "" {
+			args = append(args, "-p", port)
+			dest = d
+		}
+		if prev != "" {
+			args = append(args, "-o", "ProxyCommand="+strings.ReplaceAll(prev, "%", "%%"))
+		}
+		args = append(args, "-W", "%h:%p", dest)
+
+		words := make([]string, len(args))
+		for j, arg := range args {
+			words[j] = shellQuote(arg)
+		}
+		prev = strings.Join(words, " ")
+	}
+	return prev
+}
+
+// hopSpec formats a server as a [user@]host[:port] jump destination.
+func hopSpec(s model.Server) string {
+	spec := s.Host
+	if s.User != "" {
+		spec = s.User + "@" + spec
+	}
+	if s.Port != 0 && s.Port != 22 {
+		spec += ":" + strconv.Itoa(s.Port)
+	}
+	return spec
+}
+
+// splitHopSpec splits a literal [user@]host[:port] hop into the ssh
+// destination and the port, which is "" if there is none.
+func splitHopSpec(spec string) (dest, port string) {
+	user, hostPort := "", spec
+	if i := strings.LastIndex(spec, "@"); i != -1 {
+		user, hostPort = spec[:i+1], spec[i+1:]
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return spec, ""
+	}
+	return user + host, port
+}
+
+// findServer returns the saved server with the given name, or nil.
+func findServer(servers []model.Server, name string) *model.Server {
+	for i := range servers {
+		if servers[i].Name == name {
+			return &servers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package sshexec
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"sshh/internal/model"
+)
+
+const (
+	probeInterval = 200 * time.Millisecond
+	probeTimeout  = 500 * time.Millisecond
+
+	// remoteReadyWindow is how long ssh must stay alive before a remote
+	// forward counts as up. Remote listeners can't be probed from here, so
+	// this relies on ExitOnForwardFailure to turn a rejected forward into an exit.
+	remoteReadyWindow = 5 * time.Second
+)
+
+// localAddr returns the loopback address a forward listens on locally,
+// or "" for remote forwards whose listener lives on the server.
+func localAddr(f model.Forward) string {
+	switch f.Type {
+	case model.TunnelLocal, model.TunnelDynamic:
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(f.LocalPort))
+	}
+	return ""
+}
+
+// checkPortsFree fails if something is already listening on one of the
+// tunnel's local ports, since the readiness probe would otherwise report it.
+func checkPortsFree(t model.Tunnel) error {
+	for _, f := range t.Forwards {
+		addr := localAddr(f)
+		if addr == "" {
+			continue
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("local port %d is already in use", f.LocalPort)
+		}
+		ln.Close()
+	}
+	return nil
+}
+
+// waitReady returns a channel that is closed once every forward accepts
+// traffic: the local port answers for local forwards, a SOCKS5 handshake
+// succeeds for dynamic ones. A tunnel with only remote forwards is ready
+// after remoteReadyWindow. Probing stops early when stop is closed.
+func waitReady(t model.Tunnel, stop <-chan struct{}) <-chan struct{} {
+	ready := make(chan struct{})
+
+	var pending []model.Forward
+	for _, f := range t.Forwards {
+		if localAddr(f) != "" {
+			pending = append(pending, f)
+		}
+	}
+
+	go func() {
+		if len(pending) == 0 {
+			select {
+			case <-time.After(remoteReadyWindow):
+				close(ready)
+			case <-stop:
+			}
+			return
+		}
+
+		ticker := time.NewTicker(probeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			// ssh binds every listener at once, but probe them all anyway
+			// and drop each one as it answers.
+			remaining := pending[:0]
+			for _, f := range pending {
+				ok := false
+				if f.Type == model.TunnelDynamic {
+					ok = probeSOCKS5(localAddr(f))
+				} else {
+					ok = probeTCP(localAddr(f))
+				}
+				if !ok {
+					remaining = append(remaining, f)
+				}
+			}
+			pending = remaining
+			if len(pending) == 0 {
+				close(ready)
+				return
+			}
+		}
+	}()
+	return ready
+}
+
+// probeTCP reports whether addr accepts a TCP connection.
+func probeTCP(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, probeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// probeSOCKS5 reports whether addr speaks SOCKS5 and accepts
+// unauthenticated clients, which is what ssh -D offers.
+func probeSOCKS5(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, probeTimeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(probeTimeout))
+
+	// Version 5, one method offered: 0x00 (no authentication).
+	if _, err := conn.Write([]byte{0x05, 0x01, 0x00}); err != nil {
+		return false
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return false
+	}
+	return reply[0] == 0x05 && reply[1] == 0x00
+}
@@ -0,0 +1,181 @@
+package sshexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"sshh/internal/model"
+)
+
+const (
+	backoffInitial = time.Second
+	backoffMax     = time.Minute
+
+	// stableAfter resets the backoff once a connection has lasted this long,
+	// so a tunnel that drops once a day doesn't wait a full minute to return.
+	stableAfter = time.Minute
+)
+
+// errConnectFailed is returned when ssh exits before the tunnel came up.
+var errConnectFailed = errors.New("tunnel failed to connect")
+
+// Supervisor runs a tunnel's ssh process and restarts it according to the
+// tunnel's restart policy, with exponential backoff and jitter between attempts.
+type Supervisor struct {
+	Tunnel  model.Tunnel
+	Servers []model.Server
+
+	// Stdio for the ssh process; nil means /dev/null.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Log receives reconnect attempts. May be nil.
+	Log *log.Logger
+
+	// Optional callbacks, invoked on the goroutine calling Run.
+	OnStart func(pid int)
+	OnReady func()
+	OnRetry func(err error, attempt int, delay time.Duration)
+}
+
+// Run supervises the tunnel until it exits for good or ctx is cancelled.
+// Cancellation stops ssh and is not reported as an error.
+func (s *Supervisor) Run(ctx context.Context) error {
+	attempt := 0
+	for first := true; ; first = false {
+		started := time.Now()
+		ready, err := false, checkPortsFree(s.Tunnel)
+		switch {
+		case err != nil && first:
+			// Taken before the tunnel ever ran: something else owns the port.
+			return err
+		case err == nil:
+			var cmd *exec.Cmd
+			// Failing to build or start the command is not something a retry fixes.
+			if cmd, err = s.start(); err != nil {
+				return err
+			}
+			ready, err = s.wait(ctx, cmd)
+			if ctx.Err() != nil || isInterrupt(err) {
+				return nil
+			}
+		}
+		// On a reconnect, a port still held for a moment by the ssh that
+		// just exited counts as a failed attempt and is retried below.
+		if !s.shouldRestart(err) {
+			if err != nil && !ready {
+				return errConnectFailed
+			}
+			return err
+		}
+
+		if time.Since(started) >= stableAfter {
+			attempt = 0
+		}
+		if s.Tunnel.MaxRetries > 0 && attempt >= s.Tunnel.MaxRetries {
+			return fmt.Errorf("giving up after %d retries: %v", attempt, exitReason(err))
+		}
+		attempt++
+		delay := backoff(attempt)
+
+		if s.Log != nil {
+			s.Log.Printf("tunnel %q down (%s); reconnecting in %s (attempt %d)",
+				s.Tunnel.Name, exitReason(err), delay.Round(100*time.Millisecond), attempt)
+		}
+		if s.OnRetry != nil {
+			s.OnRetry(err, attempt, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// start launches a fresh ssh process for the tunnel.
+func (s *Supervisor) start() (*exec.Cmd, error) {
+	cmd, err := TunnelCommand(s.Tunnel, s.Servers)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = s.Stdin
+	cmd.Stdout = s.Stdout
+	cmd.Stderr = s.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if s.OnStart != nil {
+		s.OnStart(cmd.Process.Pid)
+	}
+	return cmd, nil
+}
+
+// wait blocks until ssh exits, stopping it if ctx is cancelled. ready reports
+// whether the forward came up before ssh exited.
+func (s *Supervisor) wait(ctx context.Context, cmd *exec.Cmd) (ready bool, err error) {
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	stopProbe := make(chan struct{})
+	defer close(stopProbe)
+	readyC := waitReady(s.Tunnel, stopProbe)
+	for {
+		select {
+		case err := <-done:
+			return ready, err
+		case <-readyC:
+			ready = true
+			readyC = nil
+			if s.OnReady != nil {
+				s.OnReady()
+			}
+		case <-ctx.Done():
+			_ = cmd.Process.Signal(syscall.SIGTERM)
+			return ready, <-done
+		}
+	}
+}
+
+// shouldRestart applies the tunnel's restart policy to an ssh exit.
+func (s *Supervisor) shouldRestart(err error) bool {
+	switch s.Tunnel.Restart {
+	case model.RestartAlways:
+		return true
+	case model.RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before the given restart attempt (1-based):
+// doubling from backoffInitial up to backoffMax, with ±20% jitter.
+func backoff(attempt int) time.Duration {
+	d := backoffInitial
+	for i := 1; i < attempt && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	jitter := 0.8 + 0.4*rand.Float64()
+	return time.Duration(float64(d) * jitter)
+}
+
+// exitReason describes an ssh exit for log lines.
+func exitReason(err error) string {
+	if err == nil {
+		return "exit 0"
+	}
+	return err.Error()
+}
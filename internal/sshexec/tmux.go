@@ -0,0 +1,41 @@
+package sshexec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// OpenTmux opens `sshh connect <name>` for each server in the current tmux session,
+// either as new windows or as panes tiled into the current window.
+func OpenTmux(names []string, panes bool) error {
+	if os.Getenv("TMUX") == "" {
+		return errors.New("not running inside tmux")
+	}
+	tmux, err := exec.LookPath("tmux")
+	if err != nil {
+		return fmt.Errorf("tmux not found in PATH: %w", err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		args := []string{"new-window", "-d", "-n", name, self, "connect", name}
+		if panes {
+			args = []string{"split-window", "-d", self, "connect", name}
+		}
+		if out, err := exec.Command(tmux, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux %s: %v: %s", args[0], err, out)
+		}
+		// Re-tile after every split so later splits still have room.
+		if panes {
+			if out, err := exec.Command(tmux, "select-layout", "tiled").CombinedOutput(); err != nil {
+				return fmt.Errorf("tmux select-layout: %v: %s", err, out)
+			}
+		}
+	}
+	return nil
+}
@@ -1,36 +1,50 @@
 package sshexec
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
+	"sshh/internal/config"
 	"sshh/internal/model"
 )
 
-// RunTunnel starts an SSH tunnel and blocks until it exits.
+// TunnelCommand builds the ssh command that holds a tunnel open.
 // Uses -N to skip remote command execution (port-forward only).
-// Prints a connected banner only after SSH has been alive for a short window,
-// so fast failures (connection refused, auth errors) surface as errors instead.
-func RunTunnel(t model.Tunnel) error {
+// t must already have any server reference resolved; jump hosts are
+// resolved against servers. The caller wires up stdio.
+func TunnelCommand(t model.Tunnel, servers []model.Server) (*exec.Cmd, error) {
 	sshBin, err := exec.LookPath("ssh")
 	if err != nil {
-		return fmt.Errorf("ssh not found in PATH: %w", err)
+		return nil, fmt.Errorf("ssh not found in PATH: %w", err)
 	}
 
-	args := []string{"-N"}
+	if len(t.Forwards) == 0 {
+		return nil, fmt.Errorf("tunnel %q has no forwards", t.Name)
+	}
+
+	// User options come first: ssh keeps the first value it sees, so they
+	// can override the defaults below.
+	args := append([]string{"-N"}, optionArgs(t.SSHOptions)...)
 
-	switch t.Type {
-	case model.TunnelLocal:
-		args = append(args, "-L",
-			fmt.Sprintf("127.0.0.1:%d:%s:%d", t.LocalPort, t.RemoteHost, t.RemotePort))
-	case model.TunnelRemote:
-		args = append(args, "-R",
-			fmt.Sprintf("%d:127.0.0.1:%d", t.RemotePort, t.LocalPort))
-	case model.TunnelDynamic:
-		args = append(args, "-D", fmt.Sprintf("127.0.0.1:%d", t.LocalPort))
+	for _, f := range t.Forwards {
+		switch f.Type {
+		case model.TunnelLocal:
+			args = append(args, "-L",
+				fmt.Sprintf("127.0.0.1:%d:%s:%d", f.LocalPort, f.RemoteHost, f.RemotePort))
+		case model.TunnelRemote:
+			args = append(args, "-R",
+				fmt.Sprintf("%d:127.0.0.1:%d", f.RemotePort, f.LocalPort))
+		case model.TunnelDynamic:
+			args = append(args, "-D", fmt.Sprintf("127.0.0.1:%d", f.LocalPort))
+		}
 	}
 
 	if t.SSHPort != 0 && t.SSHPort != 22 {
@@ -40,64 +54,118 @@ func RunTunnel(t model.Tunnel) error {
 		args = append(args, "-i", t.SSHKey)
 	}
 
+	hops, err := resolveJumps(t.Server, t.SSHJump, servers)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, proxyOptions(hops, t.SSHProxyCommand)...)
+
 	// Force SSH to give up after 10 seconds if the host is unreachable.
 	// Without this, the OS TCP timeout (60-90s) would apply instead.
 	args = append(args, "-o", "ConnectTimeout=10")
 
-	target := t.SSHHost
-	if t.SSHUser != "" {
-		target = t.SSHUser + "@" + t.SSHHost
-	}
-	args = append(args, target)
+	// Exit instead of staying connected without the forward, e.g. when the
+	// port is taken, so failures surface immediately rather than silently.
+	args = append(args, "-o", "ExitOnForwardFailure=yes")
 
-	cmd := exec.Command(sshBin, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Detect a dead connection (Wi-Fi drop, laptop sleep) within ~45 seconds
+	// so ssh exits and the supervisor can reconnect.
+	args = append(args, "-o", "ServerAliveInterval=15", "-o", "ServerAliveCountMax=3")
 
-	fmt.Printf("\n  Connecting tunnel %q...\n\n", t.Name)
+	args = append(args, tunnelTarget(t))
 
-	if err := cmd.Start(); err != nil {
-		return err
+	return exec.Command(sshBin, args...), nil
+}
+
+// tunnelTarget returns the [user@]host destination of a tunnel.
+func tunnelTarget(t model.Tunnel) string {
+	if t.SSHUser != "" {
+		return t.SSHUser + "@" + t.SSHHost
 	}
+	return t.SSHHost
+}
 
-	// Wait for SSH to either exit quickly (failure) or stay alive (success).
-	done := make(chan error, 1)
-	go func() { done <- cmd.Wait() }()
+// RunTunnel starts an SSH tunnel in the foreground and blocks until it exits
+// or the user presses Ctrl+C. Tunnels with a restart policy are reconnected
+// with backoff; attempts are logged to ~/.sshh/tunnels.log.
+// Prints a connected banner as soon as the forward accepts traffic; if ssh
+// exits first (connection refused, auth errors) that is reported as an error.
+// The global and tunnel pre-connect hooks run before connecting, and the
+// post-connect hooks once the tunnel is down for good. started, if not nil,
+// is called once the pre-connect hooks have passed.
+func RunTunnel(t model.Tunnel, servers []model.Server, global model.Hooks, started func()) error {
+	logf, err := config.OpenLog("tunnels.log")
+	if err != nil {
+		return err
+	}
+	defer logf.Close()
 
-	select {
-	case err := <-done:
-		// SSH exited before the window — connection failed.
-		// SSH already printed the error to stderr.
-		if err != nil {
-			return fmt.Errorf("tunnel failed to connect")
-		}
-		return nil
-	case <-time.After(12 * time.Second):
-		// SSH is still alive — tunnel is up.
-		fmt.Printf("  Tunnel %q connected\n", t.Name)
-		switch t.Type {
-		case model.TunnelLocal:
-			fmt.Printf("  127.0.0.1:%d  →  %s:%d  (via %s)\n", t.LocalPort, t.RemoteHost, t.RemotePort, target)
-		case model.TunnelRemote:
-			fmt.Printf("  %s:%d  →  127.0.0.1:%d  (via %s)\n", target, t.RemotePort, t.LocalPort, target)
-		case model.TunnelDynamic:
-			fmt.Printf("  SOCKS proxy on 127.0.0.1:%d  (via %s)\n", t.LocalPort, target)
-		}
-		fmt.Printf("  Press Ctrl+C to disconnect\n\n")
+	hooks := Hooks{global, t.Hooks}
+	env := TunnelEnv(t)
+	if err := hooks.RunPre(env, os.Stdin, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+	if started != nil {
+		started()
 	}
 
-	// Block until the tunnel exits (Ctrl+C or server drop).
-	err = <-done
-	fmt.Printf("  Tunnel %q disconnected.\n\n", t.Name)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	target := tunnelTarget(t)
+	sup := &Supervisor{
+		Tunnel:  t,
+		Servers: servers,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		Log:     log.New(logf, "", log.LstdFlags),
+		OnReady: func() {
+			fmt.Printf("  Tunnel %q connected\n", t.Name)
+			for _, f := range t.Forwards {
+				switch f.Type {
+				case model.TunnelLocal:
+					fmt.Printf("  127.0.0.1:%d  →  %s:%d  (via %s)\n", f.LocalPort, f.RemoteHost, f.RemotePort, target)
+				case model.TunnelRemote:
+					fmt.Printf("  %s:%d  →  127.0.0.1:%d  (via %s)\n", target, f.RemotePort, f.LocalPort, target)
+				case model.TunnelDynamic:
+					fmt.Printf("  SOCKS proxy on 127.0.0.1:%d  (via %s)\n", f.LocalPort, target)
+				}
+			}
+			fmt.Printf("  Press Ctrl+C to disconnect\n\n")
+		},
+		OnRetry: func(err error, attempt int, delay time.Duration) {
+			fmt.Printf("  Tunnel %q disconnected; reconnecting in %s (attempt %d)\n\n",
+				t.Name, delay.Round(time.Second), attempt)
+		},
+	}
 
-	// Ctrl+C / signal termination is normal — don't surface as an error.
-	if isInterrupt(err) {
-		return nil
+	fmt.Printf("\n  Connecting tunnel %q...\n\n", t.Name)
+	err = sup.Run(ctx)
+	if err != errConnectFailed {
+		fmt.Printf("  Tunnel %q disconnected.\n\n", t.Name)
 	}
+	hooks.RunPost(env, ExitCode(err), os.Stdin, os.Stdout, os.Stderr)
 	return err
 }
 
+// ExitCode maps the result of a connection or Supervisor run to an exit
+// status, as passed to post hooks and recorded in history.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var sshErr *ExitError
+	if errors.As(err, &sshErr) {
+		return sshErr.Code
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
 // isInterrupt reports whether the error is a normal signal-driven exit.
 func isInterrupt(err error) bool {
 	if err == nil {
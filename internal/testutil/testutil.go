@@ -0,0 +1,34 @@
+// Package testutil holds helpers shared by the tests of other packages.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// InstallFixture points HOME at a temporary directory and copies the files in
+// dir into its .sshh. It returns the .sshh path.
+func InstallFixture(t *testing.T, dir string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sshh := filepath.Join(home, ".sshh")
+	if err := os.Mkdir(sshh, 0700); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sshh, e.Name()), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sshh
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkAction is an action applied to every marked server.
+type bulkAction int
+
+const (
+	bulkTmuxWindows bulkAction = iota
+	bulkTmuxPanes
+	bulkRun
+	bulkAddTag
+	bulkRemoveTag
+	bulkDelete
+	bulkClear
+)
+
+var bulkActionLabels = []string{
+	"Open each in a new tmux window",
+	"Open each in a tmux pane (tiled)",
+	"Run a command on all",
+	"Add a tag",
+	"Remove a tag",
+	"Delete all",
+	"Clear selection",
+}
+
+// actionMenuModel lets the user pick a bulk action for the marked servers.
+type actionMenuModel struct {
+	count  int // number of marked servers
+	cursor int
+	chosen bulkAction
+	picked bool
+	done   bool
+}
+
+func newActionMenuModel(count int) actionMenuModel {
+	return actionMenuModel{count: count}
+}
+
+func (m actionMenuModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m actionMenuModel) Update(msg tea.Msg) (actionMenuModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.done = true
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(bulkActionLabels)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.chosen = bulkAction(m.cursor)
+			m.picked = true
+			m.done = true
+		}
+	}
+	return m, nil
+}
+
+func (m actionMenuModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("%d servers selected", m.count)))
+	b.WriteString("\n\n")
+
+	for i, label := range bulkActionLabels {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = selectedStyle.Render("> ")
+			label = selectedStyle.Render(label)
+		}
+		if bulkAction(i) == bulkDelete && i != m.cursor {
+			label = dangerStyle.Render(label)
+		}
+		b.WriteString(cursor + label + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑/↓: choose | Enter: run action | Esc: back"))
+	return b.String()
+}
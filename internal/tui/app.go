@@ -1,15 +1,22 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"sshh/internal/config"
+	"sshh/internal/daemon"
 	"sshh/internal/history"
 	"sshh/internal/model"
+	"sshh/internal/probe"
 	"sshh/internal/sshconfig"
+	"sshh/internal/sshexec"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type view int
@@ -22,6 +29,9 @@ const (
 	viewTunnelList
 	viewTunnelForm
 	viewTunnelConfirm
+	viewActions
+	viewPrompt
+	viewCopy
 )
 
 // Model is the root Bubble Tea model.
@@ -30,44 +40,88 @@ type Model struct {
 	tunnelCfg *config.TunnelConfig
 	hist      *history.History
 
+	lastSessions map[string]history.Event // latest server session in the event log
+
 	// SSH mode state.
-	serverList  list.Model
-	listInited  bool
-	form        formModel
-	confirm     confirmModel
-	imprt       importModel
-	deleteIndex int
+	serverList list.Model
+	listInited bool
+	form       formModel
+	confirm    confirmModel
+	imprt      importModel
+	deleteID   string
+
+	// Tree view state.
+	treeView bool            // group servers into folders instead of the recent list
+	expanded map[string]bool // open group paths
+
+	serverStatuses map[string]probe.Result // last reachability check, keyed by server ID
+
+	showDetail bool             // show the detail pane beside the list when there is room
+	sortBy     history.Strategy // server list order
+
+	// Multi-select state.
+	marked     map[string]bool // IDs of servers marked for a bulk action
+	actions    actionMenuModel
+	prompt     promptModel
+	promptFor  bulkAction
+	bulkDelete []string // IDs of servers pending bulk deletion
+
+	copyForm copyFormModel
 
 	// Tunnel mode state.
-	tunnelList        list.Model
-	tunnelListInited  bool
-	tunnelForm        tunnelFormModel
-	tunnelConfirm     confirmModel
-	tunnelDeleteIndex int
+	tunnelList       list.Model
+	tunnelListInited bool
+	tunnelForm       tunnelFormModel
+	tunnelConfirm    confirmModel
+	tunnelDeleteID   string
+	tunnelStatuses   map[string]daemon.Status
+	tunnelPolling    bool
 
 	activeView view
 	width      int
 	height     int
 
 	// Set when user selects an action that requires leaving the TUI.
-	ConnectTo *model.Server
-	RunTunnel *model.Tunnel
+	ConnectTo  *model.Server
+	RunOn      []model.Server // run RunCommand on each of these
+	RunCommand string
+	CopyOn     *model.Server // run Transfer against this server
+	Transfer   sshexec.Transfer
+
+	// notice is a transient one-line message shown under the active list.
+	notice string
 
 	err error
 }
 
 // NewModel creates the initial app model.
 func NewModel(cfg *config.Config, tunnelCfg *config.TunnelConfig, hist *history.History) Model {
+	sessions, _ := history.LastSessions(history.KindServer) // only used for display
+	sortBy, err := history.ParseStrategy(cfg.Settings.Sort)
+	notice := ""
+	if err != nil {
+		sortBy = history.DefaultStrategy
+		notice = dangerStyle.Render("settings: " + err.Error())
+	}
 	return Model{
+		lastSessions: sessions,
+
 		cfg:        cfg,
 		tunnelCfg:  tunnelCfg,
 		hist:       hist,
+		marked:     make(map[string]bool),
+		expanded:   make(map[string]bool),
 		activeView: viewList,
+		showDetail: true,
+		sortBy:     sortBy,
+		notice:     notice,
+
+		serverStatuses: probe.LoadCache(),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	return checkServers(m.cfg.Servers)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -78,6 +132,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.refreshList()
 		m.refreshTunnelList()
 		return m, nil
+	case tunnelStatusMsg:
+		m.tunnelStatuses = msg.statuses
+		m.refreshTunnelList()
+		return m, nil
+	case tunnelActionMsg:
+		switch {
+		case msg.err != nil:
+			m.notice = dangerStyle.Render(msg.err.Error())
+		case msg.started:
+			m.notice = successStyle.Render(fmt.Sprintf("Tunnel %q started", msg.name))
+		default:
+			m.notice = successStyle.Render(fmt.Sprintf("Tunnel %q stopped", msg.name))
+		}
+		return m, fetchTunnelStatus
+	case tunnelHooksMsg:
+		return m, startTunnel(msg.name)
+	case serverStatusMsg:
+		// The next round is scheduled from here so a slow round never
+		// overlaps the following one.
+		m.serverStatuses = msg.results
+		return m, tea.Batch(m.refreshList(), tickServerStatus())
+	case serverTickMsg:
+		return m, checkServers(m.cfg.Servers)
+	case tunnelTickMsg:
+		if !m.inTunnelMode() {
+			m.tunnelPolling = false
+			return m, nil
+		}
+		return m, tea.Batch(fetchTunnelStatus, tickTunnelStatus())
 	}
 
 	switch m.activeView {
@@ -95,6 +178,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateTunnelFormView(msg)
 	case viewTunnelConfirm:
 		return m.updateTunnelConfirmView(msg)
+	case viewActions:
+		return m.updateActionsView(msg)
+	case viewPrompt:
+		return m.updatePromptView(msg)
+	case viewCopy:
+		return m.updateCopyView(msg)
 	}
 	return m, nil
 }
@@ -117,6 +206,12 @@ func (m Model) View() string {
 		return m.tunnelForm.View() + "\n"
 	case viewTunnelConfirm:
 		return m.tunnelConfirm.View() + "\n"
+	case viewActions:
+		return m.actions.View() + "\n"
+	case viewPrompt:
+		return m.prompt.View() + "\n"
+	case viewCopy:
+		return m.copyForm.View() + "\n"
 	default:
 		return m.renderListView()
 	}
@@ -124,39 +219,74 @@ func (m Model) View() string {
 
 // --- SSH server list ---
 
-func (m *Model) refreshList() {
-	sorted := m.hist.SortByRecent(m.cfg.Servers)
-
-	originalIndices := make([]int, len(sorted))
-	for i, s := range sorted {
-		idx, _ := m.cfg.FindByName(s.Name)
-		originalIndices[i] = idx
+// refreshList rebuilds the server list, keeping the cursor on the same
+// server when it is still shown. The returned command re-runs an active
+// filter over the new items.
+func (m *Model) refreshList() tea.Cmd {
+	// Drop marks for servers that were deleted.
+	for id := range m.marked {
+		if m.cfg.FindByID(id) == nil {
+			delete(m.marked, id)
+		}
 	}
 
-	items := buildListItems(sorted, originalIndices)
+	sorted := m.hist.Sort(m.cfg.Servers, m.sortBy)
 
-	w, h := m.dims()
+	// While filtering, the tree is searched as a flat list so servers in
+	// closed folders are found too.
+	filtering := m.listInited && m.serverList.FilterState() != list.Unfiltered
+	var items []list.Item
+	if m.treeView && !filtering {
+		items = buildTreeItems(sorted, m.marked, m.expanded, m.serverStatuses)
+	} else {
+		items = buildListItems(sorted, m.marked, m.serverStatuses)
+	}
+
+	w, _ := m.splitWidths()
+	_, h := m.dims()
 	if !m.listInited {
 		m.serverList = newServerList(items, w, h)
 		m.listInited = true
-	} else {
-		m.serverList.SetItems(items)
-		m.serverList.SetSize(w, h)
+		return nil
+	}
+	selected := selectedServer(m.serverList)
+	cmd := m.serverList.SetItems(items)
+	m.serverList.SetSize(w, h)
+	if selected != nil && !filtering {
+		selectServer(&m.serverList, selected.server.ID)
 	}
+	return cmd
 }
 
 func (m Model) renderListView() string {
 	if !m.listInited {
 		return titleStyle.Render("SSHH") + "\n\n" + helpStyle.Render("Loading...")
 	}
-	return m.serverList.View() + "\n" + listHelp()
+	view := m.serverList.View()
+	if listW, paneW := m.splitWidths(); paneW > 0 {
+		_, h := m.dims()
+		view = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(listW).Render(view), m.renderDetail(paneW, h))
+	}
+	return view + "\n" + m.renderNotice() + listHelp()
 }
 
 func (m Model) updateListView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	wasFiltering := m.serverList.FilterState() != list.Unfiltered
 	action, cmd := updateList(&m.serverList, msg)
 
+	// The tree is searched as a flat list; switch when filtering starts or ends.
+	if m.treeView && wasFiltering != (m.serverList.FilterState() != list.Unfiltered) {
+		cmd = tea.Batch(cmd, m.refreshList())
+	}
+
 	switch action {
 	case listActionConnect:
+		if len(m.marked) > 0 {
+			m.actions = newActionMenuModel(len(m.marked))
+			m.activeView = viewActions
+			return m, nil
+		}
 		s := selectedServer(m.serverList)
 		if s != nil {
 			srv := s.server
@@ -164,40 +294,103 @@ func (m Model) updateListView(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 	case listActionAdd:
-		m.form = newFormModel("Add Server", nil, -1)
+		m.form = newFormModel("Add Server", nil, m.cfg.Servers)
 		m.activeView = viewForm
 		return m, m.form.Init()
 	case listActionEdit:
 		s := selectedServer(m.serverList)
 		if s != nil {
-			m.form = newFormModel("Edit Server", &s.server, s.index)
+			m.form = newFormModel("Edit Server", &s.server, m.cfg.Servers)
 			m.activeView = viewForm
 			return m, m.form.Init()
 		}
 	case listActionDelete:
 		s := selectedServer(m.serverList)
 		if s != nil {
-			m.deleteIndex = s.index
-			m.confirm = newConfirmModel(fmt.Sprintf("Delete server %q?", s.server.Name))
+			m.deleteID = s.server.ID
+			prompt := fmt.Sprintf("Delete server %q?", s.server.Name)
+			deps := append(m.tunnelCfg.Dependents(s.server.Name), m.cfg.JumpDependents(s.server.Name)...)
+			if len(deps) > 0 {
+				prompt += fmt.Sprintf(" It is still used by: %s.", strings.Join(deps, ", "))
+			}
+			m.confirm = newConfirmModel(prompt)
 			m.activeView = viewConfirm
 		}
 	case listActionImport:
-		servers, err := sshconfig.Parse()
+		found, err := sshconfig.Parse()
 		if err != nil {
-			m.imprt = newImportModel(nil)
+			m.imprt = newImportModel(nil, nil)
 		} else {
 			var newServers []model.Server
-			for _, s := range servers {
+			for _, s := range found.Servers {
 				if idx, _ := m.cfg.FindByName(s.Name); idx == -1 {
 					newServers = append(newServers, s)
 				}
 			}
-			m.imprt = newImportModel(newServers)
+			var newTunnels []model.Tunnel
+			for _, t := range found.Tunnels {
+				if idx, _ := m.tunnelCfg.FindTunnelByName(t.Name); idx == -1 {
+					newTunnels = append(newTunnels, t)
+				}
+			}
+			m.imprt = newImportModel(newServers, newTunnels)
 		}
 		m.activeView = viewImport
+	case listActionCopy:
+		s := selectedServer(m.serverList)
+		if s != nil {
+			m.copyForm = newCopyFormModel(s.server)
+			m.activeView = viewCopy
+			return m, m.copyForm.Init()
+		}
+	case listActionMark:
+		s := selectedServer(m.serverList)
+		if s != nil {
+			if m.marked[s.server.ID] {
+				delete(m.marked, s.server.ID)
+			} else {
+				m.marked[s.server.ID] = true
+			}
+			m.notice = ""
+			if len(m.marked) > 0 {
+				m.notice = fmt.Sprintf("%d selected — enter: actions", len(m.marked))
+			}
+			m.refreshList()
+		}
+	case listActionToggleGroup:
+		if g := selectedGroup(m.serverList); g != nil {
+			if m.expanded[g.path] {
+				delete(m.expanded, g.path)
+			} else {
+				m.expanded[g.path] = true
+			}
+			m.refreshList()
+		}
+	case listActionToggleTree:
+		m.treeView = !m.treeView
+		m.serverList.Title = "SSHH"
+		if m.treeView {
+			m.serverList.Title = "SSHH · groups"
+		}
+		m.refreshList()
+		m.serverList.ResetSelected()
+	case listActionCycleSort:
+		m.sortBy = m.sortBy.Next()
+		m.notice = "Sorted by " + m.sortBy.Label()
+		m.refreshList()
+		m.serverList.ResetSelected()
+	case listActionToggleDetail:
+		m.showDetail = !m.showDetail
+		m.refreshList()
 	case listActionToggleMode:
 		m.activeView = viewTunnelList
+		m.notice = ""
 		m.refreshTunnelList()
+		if !m.tunnelPolling {
+			m.tunnelPolling = true
+			return m, tea.Batch(fetchTunnelStatus, tickTunnelStatus())
+		}
+		return m, fetchTunnelStatus
 	case listActionQuit:
 		return m, tea.Quit
 	}
@@ -212,16 +405,19 @@ func (m Model) updateFormView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.form.done {
 		if m.form.saved {
 			srv := m.form.ToServer()
-			if srv.Name != "" && srv.Host != "" {
-				if m.form.editing {
-					if err := m.cfg.UpdateServer(m.form.index, srv); err != nil {
-						m.err = err
-					}
-				} else {
-					if err := m.cfg.AddServer(srv); err != nil {
+			if m.form.editing {
+				oldName := m.form.base.Name
+				if err := m.saved(m.cfg.UpdateServer(srv.ID, srv)); err != nil {
+					m.err = err
+				} else if oldName != srv.Name {
+					if err := m.saved(m.tunnelCfg.RenameServer(oldName, srv.Name)); err != nil {
 						m.err = err
 					}
 				}
+			} else {
+				if err := m.saved(m.cfg.AddServer(srv)); err != nil {
+					m.err = err
+				}
 			}
 		}
 		m.activeView = viewList
@@ -237,10 +433,16 @@ func (m Model) updateConfirmView(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	if m.confirm.done {
 		if m.confirm.confirmed {
-			if err := m.cfg.DeleteServer(m.deleteIndex); err != nil {
+			if m.bulkDelete != nil {
+				if err := m.saved(m.cfg.DeleteServers(m.bulkDelete)); err != nil {
+					m.err = err
+				}
+				m.clearMarks()
+			} else if err := m.saved(m.cfg.DeleteServer(m.deleteID)); err != nil {
 				m.err = err
 			}
 		}
+		m.bulkDelete = nil
 		m.activeView = viewList
 		m.refreshList()
 	}
@@ -255,7 +457,23 @@ func (m Model) updateImportView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.imprt.done {
 		if m.imprt.imported {
 			for _, s := range m.imprt.SelectedServers() {
-				if err := m.cfg.AddServer(s); err != nil {
+				if err := m.saved(m.cfg.AddServer(s)); err != nil {
+					m.err = err
+					break
+				}
+			}
+			// A tunnel whose host wasn't imported gets that host's
+			// settings copied in instead of a dangling reference.
+			found := &config.Config{Servers: m.imprt.servers}
+			for _, t := range m.imprt.SelectedTunnels() {
+				if idx, _ := m.cfg.FindByName(t.Server); idx == -1 {
+					resolved, err := found.ResolveTunnel(t)
+					if err == nil {
+						t = resolved
+						t.Server = ""
+					}
+				}
+				if err := m.saved(m.tunnelCfg.AddTunnel(t)); err != nil {
 					m.err = err
 					break
 				}
@@ -268,10 +486,185 @@ func (m Model) updateImportView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m Model) updateCopyView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.copyForm, cmd = m.copyForm.Update(msg)
+
+	if m.copyForm.done {
+		if m.copyForm.saved {
+			srv := m.copyForm.server
+			m.CopyOn = &srv
+			m.Transfer = m.copyForm.ToTransfer()
+			return m, tea.Quit
+		}
+		m.activeView = viewList
+	}
+
+	return m, cmd
+}
+
+// --- Bulk actions on marked servers ---
+
+// markedServers returns the marked servers in config order.
+func (m Model) markedServers() []model.Server {
+	var servers []model.Server
+	for _, s := range m.cfg.Servers {
+		if m.marked[s.ID] {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+func (m *Model) clearMarks() {
+	m.marked = make(map[string]bool)
+	m.notice = ""
+}
+
+func (m Model) updateActionsView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.actions, cmd = m.actions.Update(msg)
+	if !m.actions.done {
+		return m, cmd
+	}
+
+	m.activeView = viewList
+	if !m.actions.picked {
+		return m, nil
+	}
+
+	servers := m.markedServers()
+	switch m.actions.chosen {
+	case bulkTmuxWindows, bulkTmuxPanes:
+		names := make([]string, len(servers))
+		for i, s := range servers {
+			names[i] = s.Name
+		}
+		if err := sshexec.OpenTmux(names, m.actions.chosen == bulkTmuxPanes); err != nil {
+			m.notice = dangerStyle.Render(err.Error())
+			return m, nil
+		}
+		m.clearMarks()
+		m.notice = successStyle.Render(fmt.Sprintf("Opened %d servers in tmux", len(names)))
+	case bulkRun:
+		m.promptFor = bulkRun
+		m.prompt = newPromptModel(fmt.Sprintf("Run on %d servers:", len(servers)), "uptime")
+		m.activeView = viewPrompt
+		return m, m.prompt.Init()
+	case bulkAddTag, bulkRemoveTag:
+		m.promptFor = m.actions.chosen
+		title := fmt.Sprintf("Tag to add to %d servers:", len(servers))
+		if m.actions.chosen == bulkRemoveTag {
+			title = fmt.Sprintf("Tag to remove from %d servers:", len(servers))
+		}
+		m.prompt = newPromptModel(title, "prod")
+		m.activeView = viewPrompt
+		return m, m.prompt.Init()
+	case bulkDelete:
+		m.bulkDelete = make([]string, len(servers))
+		deleting := make(map[string]bool)
+		for i, s := range servers {
+			m.bulkDelete[i] = s.ID
+			deleting[s.Name] = true
+		}
+		// Servers being deleted along with the ones they jump through don't
+		// count; tunnels are never deleted here.
+		var deps []string
+		seen := make(map[string]bool)
+		for _, s := range servers {
+			for _, name := range m.tunnelCfg.Dependents(s.Name) {
+				if !seen["tunnel "+name] {
+					seen["tunnel "+name] = true
+					deps = append(deps, name)
+				}
+			}
+			for _, name := range m.cfg.JumpDependents(s.Name) {
+				if !deleting[name] && !seen["server "+name] {
+					seen["server "+name] = true
+					deps = append(deps, name)
+				}
+			}
+		}
+		prompt := fmt.Sprintf("Delete %d servers?", len(servers))
+		if len(deps) > 0 {
+			prompt += fmt.Sprintf(" They are still used by: %s.", strings.Join(deps, ", "))
+		}
+		m.confirm = newConfirmModel(prompt)
+		m.activeView = viewConfirm
+	case bulkClear:
+		m.clearMarks()
+	}
+	m.refreshList()
+	return m, nil
+}
+
+func (m Model) updatePromptView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	if !m.prompt.done {
+		return m, cmd
+	}
+
+	m.activeView = viewList
+	if !m.prompt.submitted {
+		return m, nil
+	}
+
+	servers := m.markedServers()
+	value := m.prompt.Value()
+	switch m.promptFor {
+	case bulkRun:
+		m.RunOn = servers
+		m.RunCommand = value
+		return m, tea.Quit
+	case bulkAddTag, bulkRemoveTag:
+		ids := make([]string, len(servers))
+		for i, s := range servers {
+			ids[i] = s.ID
+		}
+		add := m.promptFor == bulkAddTag
+		err := m.cfg.EditServers(ids, func(s *model.Server) {
+			if !add {
+				s.Tags = removeString(s.Tags, value)
+			} else if !containsString(s.Tags, value) {
+				s.Tags = append(slices.Clip(s.Tags), value)
+			}
+		})
+		m.notice = successStyle.Render(fmt.Sprintf("Updated tags on %d servers", len(servers)))
+		if err := m.saved(err); err != nil {
+			m.err = err
+		}
+		m.clearMarks()
+	}
+	m.refreshList()
+	return m, nil
+}
+
+// containsString reports whether list has an entry equal to s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns list without entries equal to s.
+func removeString(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // --- Tunnel list ---
 
 func (m *Model) refreshTunnelList() {
-	items := buildTunnelListItems(m.tunnelCfg.Tunnels)
+	items := buildTunnelListItems(m.cfg, m.tunnelCfg.Tunnels, m.tunnelStatuses)
 	w, h := m.dims()
 	if !m.tunnelListInited {
 		m.tunnelList = newTunnelList(items, w, h)
@@ -286,40 +679,66 @@ func (m Model) renderTunnelListView() string {
 	if !m.tunnelListInited {
 		return tunnelTitleStyle.Render("SSHH — Tunnels") + "\n\n" + helpStyle.Render("Loading...")
 	}
-	return m.tunnelList.View() + "\n" + tunnelListHelp()
+	return m.tunnelList.View() + "\n" + m.renderNotice() + tunnelListHelp()
+}
+
+// renderNotice returns the pending notice line, if any.
+func (m Model) renderNotice() string {
+	if m.notice == "" {
+		return ""
+	}
+	return statusStyle.Render(m.notice) + "\n"
+}
+
+// inTunnelMode reports whether one of the tunnel views is active.
+func (m Model) inTunnelMode() bool {
+	switch m.activeView {
+	case viewTunnelList, viewTunnelForm, viewTunnelConfirm:
+		return true
+	}
+	return false
 }
 
 func (m Model) updateTunnelListView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	action, cmd := updateTunnelList(&m.tunnelList, msg)
 
 	switch action {
-	case tunnelListActionRun:
+	case tunnelListActionToggle:
 		t := selectedTunnel(m.tunnelList)
 		if t != nil {
-			tun := t.tunnel
-			m.RunTunnel = &tun
-			return m, tea.Quit
+			if t.status != nil &&
+				(t.status.State == daemon.StateRunning || t.status.State == daemon.StateReconnecting) {
+				return m, stopTunnel(t.tunnel.Name)
+			}
+			resolved, err := m.cfg.ResolveTunnel(t.tunnel)
+			if err != nil {
+				m.notice = dangerStyle.Render(err.Error())
+				return m, nil
+			}
+			hooks := sshexec.Hooks{m.cfg.Settings.Hooks, resolved.Hooks}
+			return m, runTunnelHooks(t.tunnel.Name, hooks, sshexec.TunnelEnv(resolved))
 		}
 	case tunnelListActionAdd:
-		m.tunnelForm = newTunnelFormModel("Add Tunnel", nil, -1)
+		m.tunnelForm = newTunnelFormModel("Add Tunnel", nil, m.tunnelCfg.Tunnels, m.cfg.Servers)
 		m.activeView = viewTunnelForm
 		return m, m.tunnelForm.Init()
 	case tunnelListActionEdit:
 		t := selectedTunnel(m.tunnelList)
 		if t != nil {
-			m.tunnelForm = newTunnelFormModel("Edit Tunnel", &t.tunnel, t.index)
+			m.tunnelForm = newTunnelFormModel("Edit Tunnel", &t.tunnel, m.tunnelCfg.Tunnels, m.cfg.Servers)
 			m.activeView = viewTunnelForm
 			return m, m.tunnelForm.Init()
 		}
 	case tunnelListActionDelete:
 		t := selectedTunnel(m.tunnelList)
 		if t != nil {
-			m.tunnelDeleteIndex = t.index
+			m.tunnelDeleteID = t.tunnel.ID
 			m.tunnelConfirm = newConfirmModel(fmt.Sprintf("Delete tunnel %q?", t.tunnel.Name))
 			m.activeView = viewTunnelConfirm
 		}
 	case tunnelListActionToggleMode:
 		m.activeView = viewList
+		m.notice = ""
 		m.refreshList()
 	case tunnelListActionQuit:
 		return m, tea.Quit
@@ -335,15 +754,13 @@ func (m Model) updateTunnelFormView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.tunnelForm.done {
 		if m.tunnelForm.saved {
 			t := m.tunnelForm.ToTunnel()
-			if t.Name != "" && t.SSHHost != "" {
-				if m.tunnelForm.editing {
-					if err := m.tunnelCfg.UpdateTunnel(m.tunnelForm.index, t); err != nil {
-						m.err = err
-					}
-				} else {
-					if err := m.tunnelCfg.AddTunnel(t); err != nil {
-						m.err = err
-					}
+			if m.tunnelForm.editing {
+				if err := m.saved(m.tunnelCfg.UpdateTunnel(t.ID, t)); err != nil {
+					m.err = err
+				}
+			} else {
+				if err := m.saved(m.tunnelCfg.AddTunnel(t)); err != nil {
+					m.err = err
 				}
 			}
 		}
@@ -360,7 +777,7 @@ func (m Model) updateTunnelConfirmView(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	if m.tunnelConfirm.done {
 		if m.tunnelConfirm.confirmed {
-			if err := m.tunnelCfg.DeleteTunnel(m.tunnelDeleteIndex); err != nil {
+			if err := m.saved(m.tunnelCfg.DeleteTunnel(m.tunnelDeleteID)); err != nil {
 				m.err = err
 			}
 		}
@@ -371,6 +788,18 @@ func (m Model) updateTunnelConfirmView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// saved filters the result of saving the config or tunnels. A conflict means
+// the save went through over another sshh's changes, so it is shown as a
+// notice and nil is returned; other errors are returned as is.
+func (m *Model) saved(err error) error {
+	var conflict *config.ConflictError
+	if errors.As(err, &conflict) {
+		m.notice = tagStyle.Render(conflict.Error())
+		return nil
+	}
+	return err
+}
+
 // dims returns the usable width and height for list views.
 func (m Model) dims() (int, int) {
 	w := m.width
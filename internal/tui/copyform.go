@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"sshh/internal/model"
+	"sshh/internal/sshexec"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	cFieldDirection = iota // virtual selector — not a textinput
+	cFieldLocal
+	cFieldRemote
+	cFieldCount
+)
+
+var cFieldLabels = [cFieldCount]string{"Direction:", "Local:", "Remote:"}
+
+// copyFormModel collects the paths for an scp transfer to or from a server.
+type copyFormModel struct {
+	server  model.Server
+	upload  bool
+	inputs  [cFieldCount]textinput.Model // index cFieldDirection is unused
+	focused int
+	done    bool
+	saved   bool
+}
+
+func newCopyFormModel(s model.Server) copyFormModel {
+	m := copyFormModel{server: s, upload: true}
+	for i := range m.inputs {
+		t := textinput.New()
+		t.Prompt = ""
+		t.CharLimit = 1024
+		m.inputs[i] = t
+	}
+	m.inputs[cFieldLocal].Placeholder = "./build/app.tar.gz"
+	m.inputs[cFieldRemote].Placeholder = "/tmp/ (relative paths start in the home directory)"
+	return m
+}
+
+func (m copyFormModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m copyFormModel) Update(msg tea.Msg) (copyFormModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.done = true
+			return m, nil
+		case "ctrl+s":
+			m.done = true
+			m.saved = m.valid()
+			return m, nil
+		case "tab", "down":
+			m.focused = (m.focused + 1) % cFieldCount
+			return m, m.updateFocus()
+		case "shift+tab", "up":
+			m.focused = (m.focused - 1 + cFieldCount) % cFieldCount
+			return m, m.updateFocus()
+		case "enter":
+			if m.focused == cFieldCount-1 {
+				m.done = true
+				m.saved = m.valid()
+				return m, nil
+			}
+			m.focused++
+			return m, m.updateFocus()
+		case "left", "right":
+			if m.focused == cFieldDirection {
+				m.upload = !m.upload
+				return m, nil
+			}
+		}
+	}
+
+	if m.focused == cFieldDirection {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m *copyFormModel) updateFocus() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := cFieldLocal; i < cFieldCount; i++ {
+		if i == m.focused {
+			cmds = append(cmds, m.inputs[i].Focus())
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// valid reports whether both paths are filled in.
+func (m copyFormModel) valid() bool {
+	t := m.ToTransfer()
+	return t.Local != "" && t.Remote != ""
+}
+
+func (m copyFormModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Copy files — %s", m.server.Name)))
+	b.WriteString("\n\n")
+
+	for i := 0; i < cFieldCount; i++ {
+		cursor := "  "
+		if i == m.focused {
+			cursor = focusedInputStyle.Render("> ")
+		}
+		var value string
+		if i == cFieldDirection {
+			value = m.renderDirection()
+		} else {
+			value = m.inputs[i].View()
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, labelStyle.Render(cFieldLabels[i]), value))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Tab/↑↓: navigate | ←/→: direction | Enter: next/copy | Esc: cancel"))
+	return b.String()
+}
+
+func (m copyFormModel) renderDirection() string {
+	up, down := "[ upload ]", "[ download ]"
+	if m.upload {
+		return selectedStyle.Render(up) + " " + helpStyle.Render(down)
+	}
+	return helpStyle.Render(up) + " " + selectedStyle.Render(down)
+}
+
+// ToTransfer converts the form inputs into a Transfer.
+func (m copyFormModel) ToTransfer() sshexec.Transfer {
+	return sshexec.Transfer{
+		Local:  strings.TrimSpace(m.inputs[cFieldLocal].Value()),
+		Remote: strings.TrimSpace(m.inputs[cFieldRemote].Value()),
+		Upload: m.upload,
+	}
+}
@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"sshh/internal/config"
+	"sshh/internal/model"
+	"sshh/internal/sshconfig"
+	"sshh/internal/sshexec"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// detailMinWidth is the narrowest terminal that still gets the detail pane.
+const detailMinWidth = 90
+
+var (
+	// Detail pane frame: a rule on the left separating it from the list.
+	detailStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(colorMuted).
+			PaddingLeft(1)
+
+	detailLabelStyle = lipgloss.NewStyle().
+				Foreground(colorSecondary).
+				Width(11)
+)
+
+// splitWidths returns the widths of the server list and the detail pane.
+// The pane width is zero when it is hidden or the terminal is too narrow.
+func (m Model) splitWidths() (int, int) {
+	w, _ := m.dims()
+	if !m.showDetail || w < detailMinWidth {
+		return w, 0
+	}
+	listW := w * 2 / 5
+	return listW, w - listW
+}
+
+// renderDetail renders the pane for whatever is selected in the server list.
+func (m Model) renderDetail(width, height int) string {
+	style := detailStyle.Width(width - 1).Height(height).MaxHeight(height)
+	inner := width - 2
+
+	if g := selectedGroup(m.serverList); g != nil {
+		return style.Render(titleStyle.Render(g.path) + "\n\n" +
+			helpStyle.Render(strings.TrimSpace(g.Description())))
+	}
+	item := selectedServer(m.serverList)
+	if item == nil {
+		return style.Render(helpStyle.Render("No server selected"))
+	}
+	s := item.server
+
+	var b strings.Builder
+	row := func(label, val string) {
+		if val == "" {
+			return
+		}
+		b.WriteString(detailLabelStyle.Render(label) + " " +
+			lipgloss.NewStyle().Width(inner-12).Render(val) + "\n")
+	}
+
+	b.WriteString(titleStyle.Render(s.Name) + "\n\n")
+	row("Host", s.Host)
+	row("User", s.User)
+	row("Port", fmt.Sprint(s.Port))
+	if s.Key != "" {
+		row("Key", s.Key+" "+keyState(s.Key))
+	}
+	if len(s.Jump) > 0 {
+		row("Jump", strings.Join(s.Jump, " → "))
+	} else {
+		row("Proxy", s.ProxyCommand)
+	}
+	row("Options", sshconfig.FormatOptions(s.Options))
+	row("Command", s.RemoteCommand)
+	row("TTY", s.RequestTTY)
+	if len(s.Tags) > 0 {
+		row("Tags", tagStyle.Render(strings.Join(s.Tags, ", ")))
+	}
+	row("Group", s.Group)
+	row("Pre-hook", s.Hooks.Pre)
+	row("Post-hook", s.Hooks.Post)
+	if item.status != nil {
+		row("Status", renderStatus(item.status)+item.status.Banner)
+	}
+
+	b.WriteString("\n")
+	if last, ok := m.hist.Last(s.ID); ok {
+		row("Last used", last.Format("2006-01-02 15:04")+" ("+ago(last)+")")
+	} else {
+		row("Last used", "never")
+	}
+	if n := m.hist.Count(s.ID); n > 0 {
+		row("Sessions", fmt.Sprint(n))
+	}
+	if e, ok := m.lastSessions[s.ID]; ok && e.Exit != nil {
+		row("Last exit", fmt.Sprintf("%d after %s", *e.Exit, e.Duration().Round(time.Second)))
+	}
+	row("Tunnels", strings.Join(m.tunnelsFor(s), ", "))
+
+	if s.Notes != "" {
+		b.WriteString("\n" + lipgloss.NewStyle().Width(inner).Render(s.Notes) + "\n")
+	}
+
+	b.WriteString("\n")
+	line, err := sshexec.CommandLine(s, m.cfg.Servers)
+	if err != nil {
+		b.WriteString(dangerStyle.Width(inner).Render(err.Error()))
+	} else {
+		b.WriteString(statusStyle.UnsetPaddingLeft().Width(inner).Render("$ " + line))
+	}
+	return style.Render(b.String())
+}
+
+// tunnelsFor returns the names of tunnels that go through the server, by
+// reference or jump, or that connect to the same host inline.
+func (m Model) tunnelsFor(s model.Server) []string {
+	names := m.tunnelCfg.Dependents(s.Name)
+	for _, t := range m.tunnelCfg.Tunnels {
+		if t.Server == "" && t.SSHHost == s.Host && !containsString(names, t.Name) {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// keyState reports whether an identity file exists, as the form and doctor
+// check it.
+func keyState(path string) string {
+	if config.CheckKey(path) != nil {
+		return dangerStyle.Render("(missing)")
+	}
+	return successStyle.Render("(found)")
+}
+
+// ago formats the time since t as a short relative duration.
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"strings"
 
+	"sshh/internal/config"
 	"sshh/internal/model"
+	"sshh/internal/sshconfig"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,30 +19,49 @@ const (
 	fieldUser
 	fieldPort
 	fieldKey
+	fieldJump
+	fieldProxy
+	fieldOptions
+	fieldCommand
+	fieldTTY
 	fieldTags
+	fieldGroup
+	fieldNotes
 	fieldCount
 )
 
 var fieldLabels = [fieldCount]string{
-	"Name:", "Host:", "User:", "Port:", "Key:", "Tags:",
+	"Name:", "Host:", "User:", "Port:", "Key:", "Jump:", "Proxy:", "Options:", "Command:", "TTY:", "Tags:", "Group:", "Notes:",
+}
+
+// serverFields maps the fields config.ValidateServer reports to inputs.
+var serverFields = map[string]int{
+	"name":        fieldName,
+	"host":        fieldHost,
+	"port":        fieldPort,
+	"key":         fieldKey,
+	"options":     fieldOptions,
+	"request_tty": fieldTTY,
 }
 
 // formModel handles add/edit server forms.
 type formModel struct {
+	base    model.Server   // fields the form doesn't edit are carried over from here
+	servers []model.Server // saved servers, to check the name against
 	inputs  [fieldCount]textinput.Model
 	focused int
 	title   string
 	editing bool // true if editing an existing server
-	index   int  // index of server being edited (-1 for new)
 	done    bool
 	saved   bool
+	errs    map[int]string // shown under each field; any block saving
 }
 
-func newFormModel(title string, s *model.Server, index int) formModel {
+func newFormModel(title string, s *model.Server, servers []model.Server) formModel {
 	m := formModel{
 		title:   title,
 		editing: s != nil,
-		index:   index,
+		servers: servers,
 	}
 
 	for i := 0; i < fieldCount; i++ {
@@ -55,15 +76,31 @@ func newFormModel(title string, s *model.Server, index int) formModel {
 	m.inputs[fieldUser].Placeholder = "root"
 	m.inputs[fieldPort].Placeholder = "22"
 	m.inputs[fieldKey].Placeholder = "~/.ssh/id_rsa (optional)"
+	m.inputs[fieldJump].Placeholder = "bastion, gw (optional, saved names or user@host)"
+	m.inputs[fieldProxy].Placeholder = "ProxyCommand, used when Jump is empty (optional)"
+	m.inputs[fieldOptions].Placeholder = "ForwardAgent=yes; Compression=yes (optional)"
+	m.inputs[fieldCommand].Placeholder = "tmux new -As main (optional, runs on connect)"
+	m.inputs[fieldTTY].Placeholder = "yes, no, force or auto (optional)"
 	m.inputs[fieldTags].Placeholder = "web, prod (optional, comma-separated)"
+	m.inputs[fieldGroup].Placeholder = "prod/eu (optional, folder path)"
+	m.inputs[fieldNotes].Placeholder = "anything worth remembering (optional)"
+	m.inputs[fieldNotes].CharLimit = 1024
 
 	if s != nil {
+		m.base = *s
 		m.inputs[fieldName].SetValue(s.Name)
 		m.inputs[fieldHost].SetValue(s.Host)
 		m.inputs[fieldUser].SetValue(s.User)
 		m.inputs[fieldPort].SetValue(strconv.Itoa(s.Port))
 		m.inputs[fieldKey].SetValue(s.Key)
+		m.inputs[fieldJump].SetValue(strings.Join(s.Jump, ", "))
+		m.inputs[fieldProxy].SetValue(s.ProxyCommand)
+		m.inputs[fieldOptions].SetValue(sshconfig.FormatOptions(s.Options))
+		m.inputs[fieldCommand].SetValue(s.RemoteCommand)
+		m.inputs[fieldTTY].SetValue(s.RequestTTY)
 		m.inputs[fieldTags].SetValue(strings.Join(s.Tags, ", "))
+		m.inputs[fieldGroup].SetValue(s.Group)
+		m.inputs[fieldNotes].SetValue(s.Notes)
 	}
 
 	m.inputs[m.focused].Focus()
@@ -82,9 +119,7 @@ func (m formModel) Update(msg tea.Msg) (formModel, tea.Cmd) {
 			m.done = true
 			return m, nil
 		case "ctrl+s":
-			m.done = true
-			m.saved = true
-			return m, nil
+			return m.save(), nil
 		case "tab", "down":
 			m.focused = (m.focused + 1) % fieldCount
 			return m, m.updateFocus()
@@ -94,9 +129,7 @@ func (m formModel) Update(msg tea.Msg) (formModel, tea.Cmd) {
 		case "enter":
 			if m.focused == fieldCount-1 {
 				// Last field: save.
-				m.done = true
-				m.saved = true
-				return m, nil
+				return m.save(), nil
 			}
 			m.focused++
 			return m, m.updateFocus()
@@ -109,6 +142,39 @@ func (m formModel) Update(msg tea.Msg) (formModel, tea.Cmd) {
 	return m, cmd
 }
 
+// save finishes the form unless a field is invalid, in which case the
+// errors are shown under their fields and focus moves to the first one.
+func (m formModel) save() formModel {
+	m.errs = make(map[int]string)
+	if _, err := sshconfig.ParseOptions(m.inputs[fieldOptions].Value()); err != nil {
+		m.errs[fieldOptions] = err.Error()
+	}
+	for _, e := range config.ValidateServer(m.ToServer(), m.servers) {
+		if field, ok := serverFields[e.Field]; ok && m.errs[field] == "" {
+			m.errs[field] = e.Message
+		}
+	}
+	if len(m.errs) > 0 {
+		m.focused = firstError(m.errs)
+		m.updateFocus()
+		return m
+	}
+	m.done = true
+	m.saved = true
+	return m
+}
+
+// firstError returns the lowest field index with an error.
+func firstError(errs map[int]string) int {
+	first := -1
+	for field := range errs {
+		if first == -1 || field < first {
+			first = field
+		}
+	}
+	return first
+}
+
 func (m *formModel) updateFocus() tea.Cmd {
 	var cmds []tea.Cmd
 	for i := 0; i < fieldCount; i++ {
@@ -134,6 +200,9 @@ func (m formModel) View() string {
 			cursor = focusedInputStyle.Render("> ")
 		}
 		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, label, input))
+		if msg := m.errs[i]; msg != "" {
+			b.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render(""), dangerStyle.Render(msg)))
+		}
 	}
 
 	b.WriteString("\n")
@@ -143,28 +212,45 @@ func (m formModel) View() string {
 
 // ToServer converts the form inputs into a Server struct.
 func (m formModel) ToServer() model.Server {
-	port := 22
-	if p, err := strconv.Atoi(strings.TrimSpace(m.inputs[fieldPort].Value())); err == nil && p > 0 {
-		port = p
-	}
+	s := m.base
+	s.Name = strings.TrimSpace(m.inputs[fieldName].Value())
+	s.Host = strings.TrimSpace(m.inputs[fieldHost].Value())
+	s.User = strings.TrimSpace(m.inputs[fieldUser].Value())
+	s.Port = parsePort(m.inputs[fieldPort].Value(), 22)
+	s.Key = strings.TrimSpace(m.inputs[fieldKey].Value())
+	s.Jump = splitList(m.inputs[fieldJump].Value())
+	s.ProxyCommand = strings.TrimSpace(m.inputs[fieldProxy].Value())
+	s.Options, _ = sshconfig.ParseOptions(m.inputs[fieldOptions].Value()) // validated by save
+	s.RemoteCommand = strings.TrimSpace(m.inputs[fieldCommand].Value())
+	s.RequestTTY = strings.TrimSpace(m.inputs[fieldTTY].Value())
+	s.Tags = splitList(m.inputs[fieldTags].Value())
+	s.Group = strings.Join(model.Server{Group: m.inputs[fieldGroup].Value()}.GroupPath(), "/")
+	s.Notes = strings.TrimSpace(m.inputs[fieldNotes].Value())
+	return s
+}
 
-	var tags []string
-	raw := strings.TrimSpace(m.inputs[fieldTags].Value())
-	if raw != "" {
-		for _, t := range strings.Split(raw, ",") {
-			t = strings.TrimSpace(t)
-			if t != "" {
-				tags = append(tags, t)
-			}
-		}
+// parsePort parses a port input. Empty input gives def; anything that isn't a
+// number gives -1, which validation rejects.
+func parsePort(raw string, def int) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def
+	}
+	p, err := strconv.Atoi(raw)
+	if err != nil {
+		return -1
 	}
+	return p
+}
 
-	return model.Server{
-		Name: strings.TrimSpace(m.inputs[fieldName].Value()),
-		Host: strings.TrimSpace(m.inputs[fieldHost].Value()),
-		User: strings.TrimSpace(m.inputs[fieldUser].Value()),
-		Port: port,
-		Key:  strings.TrimSpace(m.inputs[fieldKey].Value()),
-		Tags: tags,
+// splitList parses a comma-separated input into trimmed, non-empty entries.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
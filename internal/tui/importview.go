@@ -9,23 +9,26 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// importModel lets the user select which SSH config hosts to import.
+// importModel lets the user select which SSH config hosts and tunnels to
+// import. The cursor runs over the servers first, then the tunnels.
 type importModel struct {
 	servers  []model.Server
+	tunnels  []model.Tunnel
 	selected []bool
 	cursor   int
 	done     bool
 	imported bool
 }
 
-func newImportModel(servers []model.Server) importModel {
-	sel := make([]bool, len(servers))
+func newImportModel(servers []model.Server, tunnels []model.Tunnel) importModel {
+	sel := make([]bool, len(servers)+len(tunnels))
 	// Select all by default.
 	for i := range sel {
 		sel[i] = true
 	}
 	return importModel{
 		servers:  servers,
+		tunnels:  tunnels,
 		selected: sel,
 	}
 }
@@ -46,7 +49,7 @@ func (m importModel) Update(msg tea.Msg) (importModel, tea.Cmd) {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.servers)-1 {
+			if m.cursor < len(m.selected)-1 {
 				m.cursor++
 			}
 		case " ":
@@ -63,7 +66,7 @@ func (m importModel) Update(msg tea.Msg) (importModel, tea.Cmd) {
 }
 
 func (m importModel) View() string {
-	if len(m.servers) == 0 {
+	if len(m.selected) == 0 {
 		return titleStyle.Render("No hosts found in ~/.ssh/config") + "\n\n" +
 			helpStyle.Render("Press Esc to go back")
 	}
@@ -72,24 +75,33 @@ func (m importModel) View() string {
 	b.WriteString(titleStyle.Render("Import from ~/.ssh/config"))
 	b.WriteString("\n\n")
 
+	if len(m.servers) > 0 {
+		b.WriteString(labelStyle.Render("Servers"))
+		b.WriteString("\n")
+	}
 	for i, s := range m.servers {
-		cursor := "  "
-		if i == m.cursor {
-			cursor = selectedStyle.Render("> ")
+		desc := fmt.Sprintf("%s@%s:%d", s.User, s.Host, s.Port)
+		if len(s.Jump) > 0 {
+			desc += " via " + strings.Join(s.Jump, " → ")
+		} else if s.ProxyCommand != "" {
+			desc += " via proxy command"
 		}
+		b.WriteString(m.renderRow(i, s.Name, desc))
+	}
 
-		check := "[ ]"
-		if m.selected[i] {
-			check = successStyle.Render("[x]")
+	if len(m.tunnels) > 0 {
+		if len(m.servers) > 0 {
+			b.WriteString("\n")
 		}
-
-		desc := fmt.Sprintf("%s@%s:%d", s.User, s.Host, s.Port)
-		name := s.Name
-		if i == m.cursor {
-			name = selectedStyle.Render(name)
+		b.WriteString(labelStyle.Render("Tunnels"))
+		b.WriteString("\n")
+	}
+	for i, t := range m.tunnels {
+		var fwds []string
+		for _, f := range t.Forwards {
+			fwds = append(fwds, describeForward(f, t.Server))
 		}
-
-		b.WriteString(fmt.Sprintf("%s%s %s  %s\n", cursor, check, name, helpStyle.Render(desc)))
+		b.WriteString(m.renderRow(len(m.servers)+i, t.Name, strings.Join(fwds, ", ")))
 	}
 
 	b.WriteString("\n")
@@ -97,6 +109,21 @@ func (m importModel) View() string {
 	return b.String()
 }
 
+// renderRow renders the checkbox line for entry i.
+func (m importModel) renderRow(i int, name, desc string) string {
+	cursor := "  "
+	if i == m.cursor {
+		cursor = selectedStyle.Render("> ")
+		name = selectedStyle.Render(name)
+	}
+
+	check := "[ ]"
+	if m.selected[i] {
+		check = successStyle.Render("[x]")
+	}
+	return fmt.Sprintf("%s%s %s  %s\n", cursor, check, name, helpStyle.Render(desc))
+}
+
 // SelectedServers returns only the servers that were selected.
 func (m importModel) SelectedServers() []model.Server {
 	var result []model.Server
@@ -107,3 +134,14 @@ func (m importModel) SelectedServers() []model.Server {
 	}
 	return result
 }
+
+// SelectedTunnels returns only the tunnels that were selected.
+func (m importModel) SelectedTunnels() []model.Tunnel {
+	var result []model.Tunnel
+	for i, t := range m.tunnels {
+		if m.selected[len(m.servers)+i] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"sshh/internal/model"
+	"sshh/internal/probe"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,47 +14,75 @@ import (
 // serverItem wraps a Server for use in the bubbles list.
 type serverItem struct {
 	server model.Server
-	index  int // index in config.Servers
+	marked bool // selected for a bulk action
+	inTree bool // shown under its group in the tree view
+	depth  int  // nesting level in the tree view
+	status *probe.Result
 }
 
-func (s serverItem) Title() string       { return s.server.Name }
-func (s serverItem) FilterValue() string { return s.server.Name + " " + strings.Join(s.server.Tags, " ") }
+func (s serverItem) Title() string {
+	if s.marked {
+		return indent(s.depth) + successStyle.Render("✓ ") + s.server.Name
+	}
+	return indent(s.depth) + s.server.Name
+}
+func (s serverItem) FilterValue() string {
+	return s.server.Name + " " + strings.Join(s.server.Tags, " ") + " " + s.server.Group
+}
 func (s serverItem) Description() string {
 	desc := fmt.Sprintf("%s@%s:%d", s.server.User, s.server.Host, s.server.Port)
+	if s.server.Group != "" && !s.inTree {
+		desc = s.server.Group + "  " + desc
+	}
+	desc = indent(s.depth) + renderStatus(s.status) + desc
+	if len(s.server.Jump) > 0 {
+		desc += "  via " + strings.Join(s.server.Jump, " → ")
+	}
 	if len(s.server.Tags) > 0 {
 		desc += "  " + tagStyle.Render("["+strings.Join(s.server.Tags, ", ")+"]")
 	}
 	return desc
 }
 
-// buildListItems creates list items from servers, preserving original config indices.
-func buildListItems(servers []model.Server, originalIndices []int) []list.Item {
+// buildListItems creates list items from servers, flagging servers whose IDs
+// are marked and attaching any reachability result.
+func buildListItems(servers []model.Server, marked map[string]bool, statuses map[string]probe.Result) []list.Item {
 	items := make([]list.Item, len(servers))
 	for i, s := range servers {
-		idx := i
-		if originalIndices != nil && i < len(originalIndices) {
-			idx = originalIndices[i]
+		item := serverItem{server: s, marked: marked[s.ID]}
+		if st, ok := statuses[s.ID]; ok && probe.Checkable(s) {
+			item.status = &st
 		}
-		items[i] = serverItem{server: s, index: idx}
+		items[i] = item
 	}
 	return items
 }
 
 // listHelp returns the help bar text for the server list view.
 func listHelp() string {
-	return helpStyle.Render("Tab: tunnel mode | /: search | a: add | e: edit | d: delete | i: import | enter: connect | q: quit")
+	return helpStyle.Render("Tab: tunnel mode | /: search | t: tree/recent | p: details | s: sort | a: add | e: edit | d: delete | i: import | c: copy files | space: mark | enter: connect/actions/open folder | q: quit")
 }
 
 // selectedServer returns the currently selected server item, or nil if none.
 func selectedServer(l list.Model) *serverItem {
-	item := l.SelectedItem()
-	if item == nil {
+	s, ok := l.SelectedItem().(serverItem)
+	if !ok {
 		return nil
 	}
-	s := item.(serverItem)
 	return &s
 }
 
+// selectServer moves the cursor to the server with the given ID, if it is
+// shown.
+func selectServer(l *list.Model, id string) {
+	for i, item := range l.Items() {
+		if s, ok := item.(serverItem); ok && s.server.ID == id {
+			l.Select(i)
+			return
+		}
+	}
+}
+
 // newServerList creates a configured bubbles list for servers.
 func newServerList(items []list.Item, width, height int) list.Model {
 	delegate := list.NewDefaultDelegate()
@@ -80,7 +109,13 @@ const (
 	listActionEdit
 	listActionDelete
 	listActionImport
+	listActionCopy
+	listActionMark
 	listActionToggleMode
+	listActionToggleGroup
+	listActionToggleTree
+	listActionToggleDetail
+	listActionCycleSort
 	listActionQuit
 )
 
@@ -93,6 +128,9 @@ func updateList(l *list.Model, msg tea.Msg) (listAction, tea.Cmd) {
 		}
 		switch msg.String() {
 		case "enter":
+			if selectedGroup(*l) != nil {
+				return listActionToggleGroup, nil
+			}
 			if selectedServer(*l) != nil {
 				return listActionConnect, nil
 			}
@@ -108,8 +146,22 @@ func updateList(l *list.Model, msg tea.Msg) (listAction, tea.Cmd) {
 			}
 		case "i":
 			return listActionImport, nil
+		case "c":
+			if selectedServer(*l) != nil {
+				return listActionCopy, nil
+			}
+		case " ":
+			if selectedServer(*l) != nil {
+				return listActionMark, nil
+			}
 		case "tab":
 			return listActionToggleMode, nil
+		case "t":
+			return listActionToggleTree, nil
+		case "p":
+			return listActionToggleDetail, nil
+		case "s":
+			return listActionCycleSort, nil
 		case "q", "ctrl+c":
 			return listActionQuit, nil
 		}
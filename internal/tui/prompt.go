@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptModel asks for a single line of text.
+type promptModel struct {
+	title     string
+	input     textinput.Model
+	submitted bool
+	done      bool
+}
+
+func newPromptModel(title, placeholder string) promptModel {
+	t := textinput.New()
+	t.Prompt = "> "
+	t.Placeholder = placeholder
+	t.CharLimit = 1024
+	t.Focus()
+	return promptModel{title: title, input: t}
+}
+
+func (m promptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m promptModel) Update(msg tea.Msg) (promptModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.done = true
+			return m, nil
+		case "enter":
+			m.done = true
+			m.submitted = m.Value() != ""
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m promptModel) View() string {
+	return titleStyle.Render(m.title) + "\n\n" + m.input.View() + "\n\n" +
+		helpStyle.Render("Enter: confirm | Esc: cancel")
+}
+
+// Value returns the trimmed input.
+func (m promptModel) Value() string {
+	return strings.TrimSpace(m.input.Value())
+}
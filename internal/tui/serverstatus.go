@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sshh/internal/model"
+	"sshh/internal/probe"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// serverStatusInterval is the pause between reachability checks.
+	serverStatusInterval = 30 * time.Second
+	// serverStatusTimeout bounds each connect and banner read.
+	serverStatusTimeout = 3 * time.Second
+	// serverStatusConcurrency caps how many servers are dialled at once.
+	serverStatusConcurrency = 16
+)
+
+// serverStatusMsg carries a round of reachability results, keyed by server ID.
+type serverStatusMsg struct {
+	results map[string]probe.Result
+}
+
+// serverTickMsg triggers the next reachability check.
+type serverTickMsg struct{}
+
+// checkServers probes the servers in the background and caches the results
+// so the next start can show them straight away.
+func checkServers(servers []model.Server) tea.Cmd {
+	servers = append([]model.Server(nil), servers...)
+	return func() tea.Msg {
+		results := probe.CheckAll(context.Background(), servers, serverStatusConcurrency, serverStatusTimeout)
+		_ = probe.SaveCache(results)
+		return serverStatusMsg{results: results}
+	}
+}
+
+// tickServerStatus schedules the next reachability check.
+func tickServerStatus() tea.Cmd {
+	return tea.Tick(serverStatusInterval, func(time.Time) tea.Msg { return serverTickMsg{} })
+}
+
+// renderStatus returns the status dot and latency shown before a server's
+// description, or "" if it hasn't been checked.
+func renderStatus(r *probe.Result) string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Up():
+		return successStyle.Render("●") + " " + formatRTT(r.RTT) + "  "
+	case r.Reachable:
+		return tagStyle.Render("●") + " " + r.Error + "  "
+	default:
+		return dangerStyle.Render("●") + " " + r.Error + "  "
+	}
+}
+
+// formatRTT formats a round-trip time in whole milliseconds.
+func formatRTT(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1ms"
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
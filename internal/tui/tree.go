@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sshh/internal/model"
+	"sshh/internal/probe"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// groupItem is a collapsible folder header in the tree view.
+type groupItem struct {
+	path     string // full slash-separated path, the key for expanded state
+	name     string // last path segment
+	depth    int
+	count    int // servers in this group and below
+	expanded bool
+}
+
+func (g groupItem) Title() string {
+	arrow := "▸"
+	if g.expanded {
+		arrow = "▾"
+	}
+	return indent(g.depth) + arrow + " " + g.name
+}
+func (g groupItem) Description() string {
+	noun := "servers"
+	if g.count == 1 {
+		noun = "server"
+	}
+	return indent(g.depth) + fmt.Sprintf("  %d %s", g.count, noun)
+}
+
+// FilterValue is only used by the list's filter, which runs over the flat
+// server list instead.
+func (g groupItem) FilterValue() string { return g.path }
+
+// groupNode is one folder while the tree is being built.
+type groupNode struct {
+	path     string
+	children map[string]*groupNode
+	servers  []serverItem
+	count    int
+}
+
+func newGroupNode(path string) *groupNode {
+	return &groupNode{path: path, children: make(map[string]*groupNode)}
+}
+
+// buildTreeItems lays servers out under collapsible group headers. Servers
+// keep their given order within a group; folders are sorted by name and come
+// before the servers beside them. Only groups in expanded are open.
+func buildTreeItems(servers []model.Server, marked, expanded map[string]bool, statuses map[string]probe.Result) []list.Item {
+	root := newGroupNode("")
+	for i, item := range buildListItems(servers, marked, statuses) {
+		node := root
+		for _, seg := range servers[i].GroupPath() {
+			node.count++
+			child, ok := node.children[seg]
+			if !ok {
+				child = newGroupNode(strings.TrimPrefix(node.path+"/"+seg, "/"))
+				node.children[seg] = child
+			}
+			node = child
+		}
+		node.count++
+		node.servers = append(node.servers, item.(serverItem))
+	}
+
+	var items []list.Item
+	var walk func(node *groupNode, depth int)
+	walk = func(node *groupNode, depth int) {
+		names := make([]string, 0, len(node.children))
+		for name := range node.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := node.children[name]
+			open := expanded[child.path]
+			items = append(items, groupItem{
+				path: child.path, name: name, depth: depth, count: child.count, expanded: open,
+			})
+			if open {
+				walk(child, depth+1)
+			}
+		}
+		for _, s := range node.servers {
+			s.inTree = true
+			s.depth = depth
+			items = append(items, s)
+		}
+	}
+	walk(root, 0)
+	return items
+}
+
+// indent returns the leading space for a tree item at depth.
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// selectedGroup returns the currently selected folder, or nil if the
+// selection is a server or there is none.
+func selectedGroup(l list.Model) *groupItem {
+	g, ok := l.SelectedItem().(groupItem)
+	if !ok {
+		return nil
+	}
+	return &g
+}
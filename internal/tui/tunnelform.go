@@ -5,114 +5,230 @@ import (
 	"strconv"
 	"strings"
 
+	"sshh/internal/config"
 	"sshh/internal/model"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Field indices for the tunnel form (navigation order).
+// Field indices for the fixed connection fields (navigation order).
+// Forward fields follow them, tFwdFieldCount per forward.
 const (
-	tFieldName       = 0
-	tFieldSSHHost    = 1
-	tFieldSSHUser    = 2
-	tFieldSSHPort    = 3
-	tFieldSSHKey     = 4
-	tFieldType       = 5 // virtual selector — not a textinput
-	tFieldLocalPort  = 6
-	tFieldRemoteHost = 7
-	tFieldRemotePort = 8
-	tFieldCount      = 9
-
-	tInputCount = 8 // number of actual textinputs (all fields except type)
+	tFieldName    = 0
+	tFieldServer  = 1 // virtual selector — not a textinput
+	tFieldSSHHost = 2
+	tFieldSSHUser = 3
+	tFieldSSHPort = 4
+	tFieldSSHKey  = 5
+	tFieldSSHJump = 6
+	tFixedCount   = 7
 )
 
-var tFieldLabels = [tFieldCount]string{
-	"Name:", "SSH Host:", "SSH User:", "SSH Port:", "SSH Key:",
+// Field offsets within one forward's block.
+const (
+	tFwdType       = 0 // virtual selector — not a textinput
+	tFwdLocalPort  = 1
+	tFwdRemoteHost = 2
+	tFwdRemotePort = 3
+	tFwdFieldCount = 4
+)
+
+var tFieldLabels = [tFixedCount]string{
+	"Name:", "Server:", "SSH Host:", "SSH User:", "SSH Port:", "SSH Key:", "SSH Jump:",
+}
+
+var tFwdLabels = [tFwdFieldCount]string{
 	"Type:", "Local Port:", "Remote Host:", "Remote Port:",
 }
 
+// tunnelFields maps the fields config.ValidateTunnel reports to inputs;
+// forward fields are offsets within the forward's block.
+var tunnelFields = map[string]int{
+	"name":        tFieldName,
+	"server":      tFieldServer,
+	"ssh_host":    tFieldSSHHost,
+	"ssh_port":    tFieldSSHPort,
+	"ssh_key":     tFieldSSHKey,
+	"type":        tFwdType,
+	"local_port":  tFwdLocalPort,
+	"remote_host": tFwdRemoteHost,
+	"remote_port": tFwdRemotePort,
+}
+
 var tunnelTypeOptions = []model.TunnelType{
 	model.TunnelLocal,
 	model.TunnelRemote,
 	model.TunnelDynamic,
 }
 
-// tInputIdx maps a focused field index to a textinput array index.
-// Returns -1 for the virtual type selector field.
-func tInputIdx(field int) int {
-	if field == tFieldType {
-		return -1
+// forwardInputs holds the inputs for one forward in the tunnel form.
+type forwardInputs struct {
+	tunnelType model.TunnelType
+	inputs     [tFwdFieldCount]textinput.Model // index tFwdType is unused
+}
+
+func newForwardInputs(f *model.Forward) forwardInputs {
+	fi := forwardInputs{tunnelType: model.TunnelLocal}
+	for i := range fi.inputs {
+		fi.inputs[i] = newTunnelInput()
 	}
-	if field > tFieldType {
-		return field - 1
+	fi.inputs[tFwdLocalPort].Placeholder = "8080"
+	fi.inputs[tFwdRemoteHost].Placeholder = "db.internal (not needed for dynamic)"
+	fi.inputs[tFwdRemotePort].Placeholder = "5432 (not needed for dynamic)"
+
+	if f != nil {
+		fi.tunnelType = f.Type
+		if f.LocalPort > 0 {
+			fi.inputs[tFwdLocalPort].SetValue(strconv.Itoa(f.LocalPort))
+		}
+		fi.inputs[tFwdRemoteHost].SetValue(f.RemoteHost)
+		if f.RemotePort > 0 {
+			fi.inputs[tFwdRemotePort].SetValue(strconv.Itoa(f.RemotePort))
+		}
 	}
-	return field
+	return fi
+}
+
+func newTunnelInput() textinput.Model {
+	inp := textinput.New()
+	inp.Prompt = ""
+	inp.CharLimit = 256
+	return inp
 }
 
 // tunnelFormModel handles add/edit tunnel forms.
 type tunnelFormModel struct {
-	inputs     [tInputCount]textinput.Model
-	tunnelType model.TunnelType
-	focused    int
-	title      string
-	editing    bool
-	index      int
-	done       bool
-	saved      bool
+	base     model.Tunnel                 // fields the form doesn't edit are carried over from here
+	inputs   [tFixedCount]textinput.Model // index tFieldServer is unused
+	tunnels  []model.Tunnel               // saved tunnels, to check the name and ports against
+	servers  []model.Server               // saved servers offered by the picker
+	server   int                          // index into servers, -1 for none
+	forwards []forwardInputs
+	focused  int
+	title    string
+	editing  bool
+	done     bool
+	saved    bool
+	errs     map[int]string // shown under each field; any block saving
 }
 
-func newTunnelFormModel(title string, t *model.Tunnel, index int) tunnelFormModel {
+func newTunnelFormModel(title string, t *model.Tunnel, tunnels []model.Tunnel, servers []model.Server) tunnelFormModel {
 	m := tunnelFormModel{
-		title:      title,
-		editing:    t != nil,
-		index:      index,
-		tunnelType: model.TunnelLocal,
+		title:   title,
+		editing: t != nil,
+		tunnels: tunnels,
+		servers: servers,
+		server:  -1,
 	}
 
-	for i := 0; i < tInputCount; i++ {
-		inp := textinput.New()
-		inp.Prompt = ""
-		inp.CharLimit = 256
-		m.inputs[i] = inp
+	for i := 0; i < tFixedCount; i++ {
+		m.inputs[i] = newTunnelInput()
 	}
 
-	// Placeholders (input array indices 0-7 map to fields 0-4, 6-8).
-	m.inputs[0].Placeholder = "my-tunnel"
-	m.inputs[1].Placeholder = "server.example.com"
-	m.inputs[2].Placeholder = "root"
-	m.inputs[3].Placeholder = "22"
-	m.inputs[4].Placeholder = "~/.ssh/id_rsa (optional)"
-	m.inputs[5].Placeholder = "8080"
-	m.inputs[6].Placeholder = "db.internal (not needed for dynamic)"
-	m.inputs[7].Placeholder = "5432 (not needed for dynamic)"
+	m.inputs[tFieldName].Placeholder = "my-tunnel"
 
 	if t != nil {
-		m.inputs[0].SetValue(t.Name)
-		m.inputs[1].SetValue(t.SSHHost)
-		m.inputs[2].SetValue(t.SSHUser)
+		m.base = *t
+		m.inputs[tFieldName].SetValue(t.Name)
+		for i, s := range servers {
+			if s.Name == t.Server {
+				m.server = i
+			}
+		}
+		m.inputs[tFieldSSHHost].SetValue(t.SSHHost)
+		m.inputs[tFieldSSHUser].SetValue(t.SSHUser)
 		sshPort := t.SSHPort
-		if sshPort == 0 {
+		if sshPort == 0 && t.Server == "" {
 			sshPort = 22
 		}
-		m.inputs[3].SetValue(strconv.Itoa(sshPort))
-		m.inputs[4].SetValue(t.SSHKey)
-		m.tunnelType = t.Type
-		if t.LocalPort > 0 {
-			m.inputs[5].SetValue(strconv.Itoa(t.LocalPort))
+		if sshPort != 0 {
+			m.inputs[tFieldSSHPort].SetValue(strconv.Itoa(sshPort))
 		}
-		if t.RemoteHost != "" {
-			m.inputs[6].SetValue(t.RemoteHost)
-		}
-		if t.RemotePort > 0 {
-			m.inputs[7].SetValue(strconv.Itoa(t.RemotePort))
+		m.inputs[tFieldSSHKey].SetValue(t.SSHKey)
+		m.inputs[tFieldSSHJump].SetValue(strings.Join(t.SSHJump, ", "))
+		for i := range t.Forwards {
+			m.forwards = append(m.forwards, newForwardInputs(&t.Forwards[i]))
 		}
 	}
+	if len(m.forwards) == 0 {
+		m.forwards = append(m.forwards, newForwardInputs(nil))
+	}
 
-	m.inputs[0].Focus()
+	m.updatePlaceholders()
+	m.inputs[tFieldName].Focus()
 	return m
 }
 
+// updatePlaceholders shows the inherited values in the SSH fields when a
+// saved server is picked, so it's clear that leaving them empty inherits.
+func (m *tunnelFormModel) updatePlaceholders() {
+	if m.server < 0 {
+		m.inputs[tFieldSSHHost].Placeholder = "server.example.com"
+		m.inputs[tFieldSSHUser].Placeholder = "root"
+		m.inputs[tFieldSSHPort].Placeholder = "22"
+		m.inputs[tFieldSSHKey].Placeholder = "~/.ssh/id_rsa (optional)"
+		m.inputs[tFieldSSHJump].Placeholder = "bastion (optional, saved names or user@host)"
+		return
+	}
+
+	s := m.servers[m.server]
+	inherit := func(v string) string {
+		if v == "" {
+			return "(inherited: none)"
+		}
+		return "(inherited: " + v + ")"
+	}
+	m.inputs[tFieldSSHHost].Placeholder = inherit(s.Host)
+	m.inputs[tFieldSSHUser].Placeholder = inherit(s.User)
+	m.inputs[tFieldSSHPort].Placeholder = inherit(strconv.Itoa(s.Port))
+	m.inputs[tFieldSSHKey].Placeholder = inherit(s.Key)
+	m.inputs[tFieldSSHJump].Placeholder = inherit(strings.Join(s.Jump, ", "))
+}
+
+// cycleServer moves the server picker through "none" and each saved server.
+func (m *tunnelFormModel) cycleServer(forward bool) {
+	n := len(m.servers) + 1 // slot 0 is "none"
+	slot := m.server + 1
+	if forward {
+		slot = (slot + 1) % n
+	} else {
+		slot = (slot - 1 + n) % n
+	}
+	m.server = slot - 1
+	m.updatePlaceholders()
+}
+
+// fieldCount returns the number of navigable fields, including every forward.
+func (m tunnelFormModel) fieldCount() int {
+	return tFixedCount + len(m.forwards)*tFwdFieldCount
+}
+
+// forwardAt maps a field index to its forward and offset within that forward.
+// Returns -1 for the fixed connection fields.
+func forwardAt(field int) (fwd, offset int) {
+	if field < tFixedCount {
+		return -1, field
+	}
+	field -= tFixedCount
+	return field / tFwdFieldCount, field % tFwdFieldCount
+}
+
+// input returns the textinput behind a field, or nil for a selector.
+func (m *tunnelFormModel) input(field int) *textinput.Model {
+	fwd, off := forwardAt(field)
+	if fwd < 0 {
+		if off == tFieldServer {
+			return nil
+		}
+		return &m.inputs[off]
+	}
+	if off == tFwdType {
+		return nil
+	}
+	return &m.forwards[fwd].inputs[off]
+}
+
 func (m tunnelFormModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -120,73 +236,121 @@ func (m tunnelFormModel) Init() tea.Cmd {
 func (m tunnelFormModel) Update(msg tea.Msg) (tunnelFormModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		fwd, off := forwardAt(m.focused)
 		switch msg.String() {
 		case "esc":
 			m.done = true
 			return m, nil
 		case "ctrl+s":
-			m.done = true
-			m.saved = true
+			return m.save(), nil
+		case "ctrl+n":
+			m.errs = nil // field indices of later forwards shift
+			m.forwards = append(m.forwards, newForwardInputs(nil))
+			m.focused = tFixedCount + (len(m.forwards)-1)*tFwdFieldCount
+			return m, m.updateFocus()
+		case "ctrl+x":
+			if fwd >= 0 && len(m.forwards) > 1 {
+				m.errs = nil
+				m.forwards = append(m.forwards[:fwd], m.forwards[fwd+1:]...)
+				if m.focused >= m.fieldCount() {
+					m.focused = m.fieldCount() - tFwdFieldCount
+				}
+				return m, m.updateFocus()
+			}
 			return m, nil
 		case "tab", "down":
-			m.focused = (m.focused + 1) % tFieldCount
+			m.focused = (m.focused + 1) % m.fieldCount()
 			return m, m.updateFocus()
 		case "shift+tab", "up":
-			m.focused = (m.focused - 1 + tFieldCount) % tFieldCount
+			m.focused = (m.focused - 1 + m.fieldCount()) % m.fieldCount()
 			return m, m.updateFocus()
 		case "enter":
-			if m.focused == tFieldCount-1 {
-				m.done = true
-				m.saved = true
-				return m, nil
+			if m.focused == m.fieldCount()-1 {
+				return m.save(), nil
 			}
 			m.focused++
 			return m, m.updateFocus()
 		case "left":
-			if m.focused == tFieldType {
-				m.cycleType(false)
+			if fwd < 0 && off == tFieldServer {
+				m.cycleServer(false)
+				return m, nil
+			}
+			if fwd >= 0 && off == tFwdType {
+				m.forwards[fwd].cycleType(false)
 				return m, nil
 			}
 		case "right":
-			if m.focused == tFieldType {
-				m.cycleType(true)
+			if fwd < 0 && off == tFieldServer {
+				m.cycleServer(true)
+				return m, nil
+			}
+			if fwd >= 0 && off == tFwdType {
+				m.forwards[fwd].cycleType(true)
 				return m, nil
 			}
 		}
 	}
 
-	// Forward to the focused textinput (skip if on type selector).
-	idx := tInputIdx(m.focused)
-	if idx >= 0 {
+	// Forward to the focused textinput (skip if on a selector).
+	if inp := m.input(m.focused); inp != nil {
 		var cmd tea.Cmd
-		m.inputs[idx], cmd = m.inputs[idx].Update(msg)
+		*inp, cmd = inp.Update(msg)
 		return m, cmd
 	}
 	return m, nil
 }
 
-func (m *tunnelFormModel) cycleType(forward bool) {
+func (f *forwardInputs) cycleType(forward bool) {
 	for i, t := range tunnelTypeOptions {
-		if t == m.tunnelType {
+		if t == f.tunnelType {
 			if forward {
-				m.tunnelType = tunnelTypeOptions[(i+1)%len(tunnelTypeOptions)]
+				f.tunnelType = tunnelTypeOptions[(i+1)%len(tunnelTypeOptions)]
 			} else {
-				m.tunnelType = tunnelTypeOptions[(i-1+len(tunnelTypeOptions))%len(tunnelTypeOptions)]
+				f.tunnelType = tunnelTypeOptions[(i-1+len(tunnelTypeOptions))%len(tunnelTypeOptions)]
 			}
 			return
 		}
 	}
-	m.tunnelType = tunnelTypeOptions[0]
+	f.tunnelType = tunnelTypeOptions[0]
+}
+
+// save finishes the form unless a field is invalid, in which case the
+// errors are shown under their fields and focus moves to the first one.
+func (m tunnelFormModel) save() tunnelFormModel {
+	m.errs = make(map[int]string)
+	for _, e := range config.ValidateTunnel(m.ToTunnel(), m.tunnels, m.servers) {
+		field, ok := tunnelFields[e.Field]
+		if !ok {
+			continue
+		}
+		if e.Forward >= 0 {
+			field += tFixedCount + e.Forward*tFwdFieldCount
+		}
+		if m.errs[field] == "" {
+			m.errs[field] = e.Message
+		}
+	}
+	if len(m.errs) > 0 {
+		m.focused = firstError(m.errs)
+		m.updateFocus()
+		return m
+	}
+	m.done = true
+	m.saved = true
+	return m
 }
 
 func (m *tunnelFormModel) updateFocus() tea.Cmd {
-	idx := tInputIdx(m.focused)
 	var cmds []tea.Cmd
-	for i := 0; i < tInputCount; i++ {
-		if i == idx {
-			cmds = append(cmds, m.inputs[i].Focus())
+	for field := 0; field < m.fieldCount(); field++ {
+		inp := m.input(field)
+		if inp == nil {
+			continue
+		}
+		if field == m.focused {
+			cmds = append(cmds, inp.Focus())
 		} else {
-			m.inputs[i].Blur()
+			inp.Blur()
 		}
 	}
 	return tea.Batch(cmds...)
@@ -197,30 +361,62 @@ func (m tunnelFormModel) View() string {
 	b.WriteString(tunnelTitleStyle.Render(m.title))
 	b.WriteString("\n\n")
 
-	for field := 0; field < tFieldCount; field++ {
-		label := tunnelLabelStyle.Render(tFieldLabels[field])
+	for field := 0; field < m.fieldCount(); field++ {
+		fwd, off := forwardAt(field)
+		if fwd >= 0 && off == 0 {
+			b.WriteString("\n")
+			b.WriteString(helpStyle.Render(fmt.Sprintf("  Forward %d of %d", fwd+1, len(m.forwards))))
+			b.WriteString("\n")
+		}
+
+		var label string
+		if fwd >= 0 {
+			label = tFwdLabels[off]
+		} else {
+			label = tFieldLabels[off]
+		}
 		cursor := "  "
 		if field == m.focused {
 			cursor = focusedInputStyle.Render("> ")
 		}
 
-		if field == tFieldType {
-			b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, label, m.renderTypeSelector()))
-		} else {
-			idx := tInputIdx(field)
-			b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, label, m.inputs[idx].View()))
+		var value string
+		switch inp := m.input(field); {
+		case inp != nil:
+			value = inp.View()
+		case fwd < 0:
+			value = m.renderServerPicker()
+		default:
+			value = m.forwards[fwd].renderTypeSelector()
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, tunnelLabelStyle.Render(label), value))
+		if msg := m.errs[field]; msg != "" {
+			b.WriteString(fmt.Sprintf("  %s %s\n", tunnelLabelStyle.Render(""), dangerStyle.Render(msg)))
 		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("Tab/↑↓: navigate | ←/→: change type | Enter: next/save | Ctrl+S: save | Esc: cancel"))
+	b.WriteString(helpStyle.Render("Tab/↑↓: navigate | ←/→: pick server/type | Ctrl+N: add forward | Ctrl+X: remove forward"))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Enter: next/save | Ctrl+S: save | Esc: cancel"))
 	return b.String()
 }
 
-func (m tunnelFormModel) renderTypeSelector() string {
+func (m tunnelFormModel) renderServerPicker() string {
+	if len(m.servers) == 0 {
+		return helpStyle.Render("(no saved servers)")
+	}
+	name := "none"
+	if m.server >= 0 {
+		name = m.servers[m.server].Name
+	}
+	return helpStyle.Render("◀ ") + selectedStyle.Render(name) + helpStyle.Render(" ▶")
+}
+
+func (f forwardInputs) renderTypeSelector() string {
 	var parts []string
 	for _, t := range tunnelTypeOptions {
-		if t == m.tunnelType {
+		if t == f.tunnelType {
 			parts = append(parts, selectedStyle.Render(fmt.Sprintf("[ %s ]", t)))
 		} else {
 			parts = append(parts, helpStyle.Render(fmt.Sprintf("[ %s ]", t)))
@@ -231,28 +427,37 @@ func (m tunnelFormModel) renderTypeSelector() string {
 
 // ToTunnel converts the form inputs into a Tunnel struct.
 func (m tunnelFormModel) ToTunnel() model.Tunnel {
+	// With a server picked, an empty port inherits the server's.
 	sshPort := 22
-	if p, err := strconv.Atoi(strings.TrimSpace(m.inputs[3].Value())); err == nil && p > 0 {
-		sshPort = p
+	server := ""
+	if m.server >= 0 {
+		sshPort = 0
+		server = m.servers[m.server].Name
 	}
-	localPort := 0
-	if p, err := strconv.Atoi(strings.TrimSpace(m.inputs[5].Value())); err == nil && p > 0 {
-		localPort = p
-	}
-	remotePort := 0
-	if p, err := strconv.Atoi(strings.TrimSpace(m.inputs[7].Value())); err == nil && p > 0 {
-		remotePort = p
+	sshPort = parsePort(m.inputs[tFieldSSHPort].Value(), sshPort)
+
+	var forwards []model.Forward
+	for _, fi := range m.forwards {
+		forwards = append(forwards, fi.toForward())
 	}
 
-	return model.Tunnel{
-		Name:       strings.TrimSpace(m.inputs[0].Value()),
-		SSHHost:    strings.TrimSpace(m.inputs[1].Value()),
-		SSHUser:    strings.TrimSpace(m.inputs[2].Value()),
-		SSHPort:    sshPort,
-		SSHKey:     strings.TrimSpace(m.inputs[4].Value()),
-		Type:       m.tunnelType,
-		LocalPort:  localPort,
-		RemoteHost: strings.TrimSpace(m.inputs[6].Value()),
-		RemotePort: remotePort,
+	t := m.base
+	t.Name = strings.TrimSpace(m.inputs[tFieldName].Value())
+	t.Server = server
+	t.SSHHost = strings.TrimSpace(m.inputs[tFieldSSHHost].Value())
+	t.SSHUser = strings.TrimSpace(m.inputs[tFieldSSHUser].Value())
+	t.SSHPort = sshPort
+	t.SSHKey = strings.TrimSpace(m.inputs[tFieldSSHKey].Value())
+	t.SSHJump = splitList(m.inputs[tFieldSSHJump].Value())
+	t.Forwards = forwards
+	return t
+}
+
+func (f forwardInputs) toForward() model.Forward {
+	return model.Forward{
+		Type:       f.tunnelType,
+		LocalPort:  parsePort(f.inputs[tFwdLocalPort].Value(), 0),
+		RemoteHost: strings.TrimSpace(f.inputs[tFwdRemoteHost].Value()),
+		RemotePort: parsePort(f.inputs[tFwdRemotePort].Value(), 0),
 	}
 }
@@ -2,7 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
+	"sshh/internal/config"
+	"sshh/internal/daemon"
 	"sshh/internal/model"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -11,44 +14,86 @@ import (
 
 // tunnelItem wraps a Tunnel for use in the bubbles list.
 type tunnelItem struct {
-	tunnel model.Tunnel
-	index  int
+	tunnel   model.Tunnel
+	resolved model.Tunnel   // with settings inherited from its server
+	missing  bool           // referenced server no longer exists
+	status   *daemon.Status // nil if the daemon isn't tracking it
 }
 
-func (t tunnelItem) Title() string       { return t.tunnel.Name }
-func (t tunnelItem) FilterValue() string { return t.tunnel.Name + " " + t.tunnel.SSHHost }
+func (t tunnelItem) Title() string {
+	if t.status == nil {
+		return t.tunnel.Name
+	}
+	switch t.status.State {
+	case daemon.StateRunning:
+		return t.tunnel.Name + "  " + successStyle.Render("● running")
+	case daemon.StateReconnecting:
+		return t.tunnel.Name + "  " + tagStyle.Render(fmt.Sprintf("↻ reconnecting (%d)", t.status.Restarts))
+	case daemon.StateFailed:
+		return t.tunnel.Name + "  " + dangerStyle.Render("✗ failed")
+	default:
+		return t.tunnel.Name + "  " + helpStyle.Render("○ "+string(t.status.State))
+	}
+}
+
+func (t tunnelItem) FilterValue() string {
+	return t.tunnel.Name + " " + t.tunnel.Server + " " + t.resolved.SSHHost
+}
 func (t tunnelItem) Description() string {
-	user := t.tunnel.SSHUser
+	user := t.resolved.SSHUser
 	if user == "" {
 		user = "~"
 	}
-	via := fmt.Sprintf("via %s@%s", user, t.tunnel.SSHHost)
-	switch t.tunnel.Type {
+	via := fmt.Sprintf("via %s@%s", user, t.resolved.SSHHost)
+	switch {
+	case t.missing:
+		via = dangerStyle.Render(fmt.Sprintf("via missing server %q", t.tunnel.Server))
+	case t.tunnel.Server != "":
+		via = fmt.Sprintf("via %s (%s@%s)", t.tunnel.Server, user, t.resolved.SSHHost)
+	}
+	if len(t.tunnel.Forwards) == 0 {
+		return via
+	}
+
+	parts := make([]string, len(t.tunnel.Forwards))
+	for i, f := range t.tunnel.Forwards {
+		parts[i] = describeForward(f, t.resolved.SSHHost)
+	}
+	return strings.Join(parts, ", ") + "  " + via
+}
+
+// describeForward renders one forward for the tunnel list.
+func describeForward(f model.Forward, sshHost string) string {
+	switch f.Type {
 	case model.TunnelLocal:
-		return fmt.Sprintf("local  127.0.0.1:%d → %s:%d  %s",
-			t.tunnel.LocalPort, t.tunnel.RemoteHost, t.tunnel.RemotePort, via)
+		return fmt.Sprintf("local  127.0.0.1:%d → %s:%d", f.LocalPort, f.RemoteHost, f.RemotePort)
 	case model.TunnelRemote:
-		return fmt.Sprintf("remote  %s:%d → 127.0.0.1:%d  %s",
-			t.tunnel.SSHHost, t.tunnel.RemotePort, t.tunnel.LocalPort, via)
+		return fmt.Sprintf("remote  %s:%d → 127.0.0.1:%d", sshHost, f.RemotePort, f.LocalPort)
 	case model.TunnelDynamic:
-		return fmt.Sprintf("dynamic SOCKS  127.0.0.1:%d  %s", t.tunnel.LocalPort, via)
+		return fmt.Sprintf("dynamic SOCKS  127.0.0.1:%d", f.LocalPort)
 	default:
-		return t.tunnel.SSHHost
+		return string(f.Type)
 	}
 }
 
-// buildTunnelListItems creates list items from tunnels.
-func buildTunnelListItems(tunnels []model.Tunnel) []list.Item {
+// buildTunnelListItems creates list items from tunnels, resolving their
+// server references and attaching any status the daemon reported for them.
+func buildTunnelListItems(cfg *config.Config, tunnels []model.Tunnel, statuses map[string]daemon.Status) []list.Item {
 	items := make([]list.Item, len(tunnels))
 	for i, t := range tunnels {
-		items[i] = tunnelItem{tunnel: t, index: i}
+		resolved, err := cfg.ResolveTunnel(t)
+		item := tunnelItem{tunnel: t, resolved: resolved, missing: err != nil}
+		if st, ok := statuses[t.Name]; ok {
+			item.status = &st
+		}
+		items[i] = item
 	}
 	return items
 }
 
 // tunnelListHelp returns the help bar text for the tunnel list view.
 func tunnelListHelp() string {
-	return helpStyle.Render("Tab: ssh mode | /: search | a: add | e: edit | d: delete | enter: run tunnel | q: quit")
+	return helpStyle.Render("Tab: ssh mode | /: search | a: add | e: edit | d: delete | enter: start/stop tunnel | q: quit")
 }
 
 // selectedTunnel returns the currently selected tunnel item, or nil if none.
@@ -81,7 +126,7 @@ type tunnelListAction int
 
 const (
 	tunnelListActionNone tunnelListAction = iota
-	tunnelListActionRun
+	tunnelListActionToggle
 	tunnelListActionAdd
 	tunnelListActionEdit
 	tunnelListActionDelete
@@ -98,7 +143,7 @@ func updateTunnelList(l *list.Model, msg tea.Msg) (tunnelListAction, tea.Cmd) {
 		switch msg.String() {
 		case "enter":
 			if selectedTunnel(*l) != nil {
-				return tunnelListActionRun, nil
+				return tunnelListActionToggle, nil
 			}
 		case "a":
 			return tunnelListActionAdd, nil
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"io"
+	"time"
+
+	"sshh/internal/daemon"
+	"sshh/internal/history"
+	"sshh/internal/sshexec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tunnelStatusInterval is how often the tunnel view polls the daemon.
+const tunnelStatusInterval = 2 * time.Second
+
+// tunnelStatusMsg carries the daemon's view of running tunnels, keyed by name.
+type tunnelStatusMsg struct {
+	statuses map[string]daemon.Status
+}
+
+// tunnelActionMsg reports the result of a start/stop request.
+type tunnelActionMsg struct {
+	name    string
+	started bool
+	err     error
+}
+
+// tunnelHooksMsg reports that a tunnel's pre-connect hooks have passed and
+// the daemon can be asked to start it.
+type tunnelHooksMsg struct {
+	name string
+}
+
+// tunnelTickMsg triggers the next status poll.
+type tunnelTickMsg struct{}
+
+// fetchTunnelStatus queries the daemon without spawning it;
+// no daemon simply means nothing is running.
+func fetchTunnelStatus() tea.Msg {
+	statuses := make(map[string]daemon.Status)
+	list, err := daemon.List()
+	if err != nil {
+		return tunnelStatusMsg{statuses: statuses}
+	}
+	for _, st := range list {
+		statuses[st.Name] = st
+	}
+	return tunnelStatusMsg{statuses: statuses}
+}
+
+// tickTunnelStatus schedules the next status poll.
+func tickTunnelStatus() tea.Cmd {
+	return tea.Tick(tunnelStatusInterval, func(time.Time) tea.Msg { return tunnelTickMsg{} })
+}
+
+// stopTunnel asks the daemon to take down the named tunnel.
+func stopTunnel(name string) tea.Cmd {
+	return func() tea.Msg {
+		return tunnelActionMsg{name: name, err: daemon.Stop(name)}
+	}
+}
+
+// startTunnel asks the daemon to bring up the named tunnel.
+func startTunnel(name string) tea.Cmd {
+	return func() tea.Msg {
+		return tunnelActionMsg{name: name, started: true, err: daemon.Start(name, history.ClientTUI)}
+	}
+}
+
+// runTunnelHooks hands the terminal to the tunnel's pre-connect hooks, which
+// may prompt (e.g. aws sso login), and starts the tunnel once they pass.
+func runTunnelHooks(name string, hooks sshexec.Hooks, env []string) tea.Cmd {
+	if !hooks.HasPre() {
+		return startTunnel(name)
+	}
+	return tea.Exec(&preHooks{hooks: hooks, env: env}, func(err error) tea.Msg {
+		if err != nil {
+			return tunnelActionMsg{name: name, started: true, err: err}
+		}
+		return tunnelHooksMsg{name: name}
+	})
+}
+
+// preHooks runs pre-connect hooks as a tea.ExecCommand.
+type preHooks struct {
+	hooks          sshexec.Hooks
+	env            []string
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+func (p *preHooks) Run() error {
+	return p.hooks.RunPre(p.env, p.stdin, p.stdout, p.stderr)
+}
+
+func (p *preHooks) SetStdin(r io.Reader)  { p.stdin = r }
+func (p *preHooks) SetStdout(w io.Writer) { p.stdout = w }
+func (p *preHooks) SetStderr(w io.Writer) { p.stderr = w }
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"sshh/internal/cli"
 	"sshh/internal/config"
 	"sshh/internal/history"
+	"sshh/internal/model"
 	"sshh/internal/sshexec"
 	"sshh/internal/tui"
 
@@ -24,6 +28,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Subcommands: sshh daemon, sshh tunnel ... They take precedence over
+	// server names; sshh connect <name> reaches a server named like one.
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if args[0] == "connect" {
+			args = args[1:]
+			if len(args) == 0 {
+				fmt.Fprintln(os.Stderr, "Usage: sshh connect <name> [-- <command>]")
+				os.Exit(2)
+			}
+		} else if cmd, ok := cli.Lookup(args[0]); ok {
+			os.Exit(cmd(args[1:]))
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
@@ -42,19 +61,28 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Direct connect mode: sshh <name>
-	if len(os.Args) > 1 {
-		name := os.Args[1]
+	// Direct connect mode: sshh [connect] <name> [-- <command>]
+	if len(args) > 0 {
+		name := args[0]
 		_, srv := cfg.FindByName(name)
 		if srv == nil {
 			fmt.Fprintf(os.Stderr, "Server %q not found\n", name)
-			os.Exit(1)
+			os.Exit(3) // as for sshh show, edit and rm
 		}
-		_ = hist.Record(srv.Name)
-		if err := sshexec.Connect(*srv); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+
+		connect := func(opts sshexec.Options) error { return sshexec.Connect(*srv, cfg.Servers, opts) }
+		if len(args) > 1 {
+			if args[1] != "--" || len(args) == 2 {
+				fmt.Fprintln(os.Stderr, "Usage: sshh [connect] <name> [-- <command>]")
+				os.Exit(2)
+			}
+			command := strings.Join(args[2:], " ")
+			connect = func(opts sshexec.Options) error {
+				return sshexec.ConnectCommand(*srv, cfg.Servers, command, opts)
+			}
 		}
+
+		exitOnError(session(hist, *srv, history.ClientCLI, connectOptions(cfg), connect))
 		return
 	}
 
@@ -73,20 +101,57 @@ func main() {
 		return
 	}
 
+	// If a command was entered for marked servers, run it after TUI exits.
+	if len(fm.RunOn) > 0 {
+		os.Exit(cli.RunOn(fm.RunOn, cfg.Servers, fm.RunCommand, 8))
+	}
+
+	// If a file transfer was requested, run it after TUI exits.
+	if fm.CopyOn != nil {
+		os.Exit(cli.RunCopy(*fm.CopyOn, cfg.Servers, fm.Transfer))
+	}
+
 	// If a server was selected, connect after TUI exits.
 	if fm.ConnectTo != nil {
-		_ = hist.Record(fm.ConnectTo.Name)
-		if err := sshexec.Connect(*fm.ConnectTo); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
+		srv := *fm.ConnectTo
+		exitOnError(session(hist, srv, history.ClientTUI, connectOptions(cfg), func(opts sshexec.Options) error {
+			return sshexec.Connect(srv, cfg.Servers, opts)
+		}))
 	}
+}
 
-	// If a tunnel was selected, run it after TUI exits.
-	if fm.RunTunnel != nil {
-		if err := sshexec.RunTunnel(*fm.RunTunnel); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
+// connectOptions returns the connection settings from the config.
+func connectOptions(cfg *config.Config) sshexec.Options {
+	return sshexec.Options{Hooks: cfg.Settings.Hooks, Child: cfg.Settings.SSHAsChild}
+}
+
+// session runs connect with opts and records the connection in history once
+// ssh is about to start, so a failed pre-connect hook isn't counted. The end
+// of the session is only recorded if connect returns, i.e. when ssh ran as a
+// child or failed to start.
+func session(hist *history.History, srv model.Server, client string, opts sshexec.Options, connect func(sshexec.Options) error) error {
+	var ev history.Event
+	opts.Started = func() {
+		// Begin first: a missing event log is seeded from history, which
+		// must not include this session yet.
+		ev, _ = history.Begin(history.KindServer, srv.ID, srv.Name, client)
+		_ = hist.Record(srv.ID)
+	}
+	err := connect(opts)
+	_ = history.Finish(ev, sshexec.ExitCode(err))
+	return err
+}
+
+// exitOnError exits if a connection failed. A non-zero ssh status is passed
+// through as is, since ssh has already reported why.
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	var exitErr *sshexec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
 	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
 }